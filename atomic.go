@@ -0,0 +1,97 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxTempAttempts is the number of names tried
+// before giving up creating a temporary file.
+const maxTempAttempts = 10000
+
+var (
+	randMu  sync.Mutex
+	randSrc = rand.New(rand.NewSource(time.Now().UnixNano() + int64(os.Getpid())))
+)
+
+// nextRandom returns a random string of
+// decimal digits, used to build temporary names.
+func nextRandom() string {
+	randMu.Lock()
+	r := randSrc.Uint32()
+	randMu.Unlock()
+	return strconv.Itoa(int(1e9 + r%1e9))[1:]
+}
+
+// WriteFileAtomic writes buf to the named file so that readers
+// observe either the previous content of the file or buf,
+// never a mix of them.
+//
+// buf is first written to a temporary file created in the same
+// directory of name with permissions perm. The temporary file is
+// synced, if the FileWriter returned by OpenFile implements
+// SyncerFile, and then renamed over name using fsys.Rename.
+// Since name is replaced, it takes the permissions of the
+// temporary file even when it already exists.
+//
+// fsys must implement RenameFS: the copy fallback used by Rename
+// function is not atomic, so WriteFileAtomic returns an error
+// without writing anything when fsys does not implement RenameFS.
+// A wrapper implementing RenameFS can still fail the rename,
+// for example because the file system it wraps does not
+// support it: in that case, as on any other failure after
+// its creation, the temporary file is deleted.
+//
+// Number of bytes written is returned, and an error if any.
+// If there is an error, it will be of type *fs.PathError.
+func WriteFileAtomic(fsys fs.FS, name string, buf []byte, perm fs.FileMode) (int, error) {
+	if !fs.ValidPath(name) || name == "." {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return 0, &fs.PathError{Op: "WriteFileAtomic", Path: name, Err: err}
+	}
+
+	rfs, ok := fsys.(RenameFS)
+	if !ok {
+		err := fmt.Errorf("%w fsys: does not implement RenameFS", fs.ErrInvalid)
+		return 0, &fs.PathError{Op: "WriteFileAtomic", Path: name, Err: err}
+	}
+
+	dir, base := path.Split(name)
+	next := func() string {
+		return dir + "." + base + ".tmp" + nextRandom()
+	}
+	file, tmpName, err := createTempFile(fsys, next, WriteOnly, perm)
+	if err != nil {
+		return 0, opPathError("WriteFileAtomic", name, err)
+	}
+
+	n, err := file.Write(buf)
+	if s, ok := file.(SyncerFile); ok && err == nil {
+		err = s.Sync()
+	}
+	if errClose := file.Close(); errClose != nil && err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = rfs.Rename(tmpName, name)
+	}
+
+	if err != nil {
+		// best effort removal of the temporary file
+		OpenFile(fsys, tmpName, Truncate, 0)
+
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			err = perr.Err
+		}
+		return 0, &fs.PathError{Op: "WriteFileAtomic", Path: name, Err: err}
+	}
+	return n, nil
+}
@@ -0,0 +1,95 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRenameFS is a MemFS whose
+// Rename method always fails.
+type failingRenameFS struct {
+	*writefs.MemFS
+}
+
+func (fsys failingRenameFS) Rename(oldname, newname string) error {
+	return &fs.PathError{Op: "Rename", Path: oldname, Err: errors.New("expected")}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	data := []byte("atomic")
+
+	for name, fsys := range map[string]func() writefs.WriteFS{
+		"MemFS": func() writefs.WriteFS { return newTreeFS() },
+		"DirFS": func() writefs.WriteFS { return newTreeDirFS(t) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("replaces existing files", func(t *testing.T) {
+				fsys := fsys()
+				n, err := writefs.WriteFileAtomic(fsys, "dir1/file2", data, 0600)
+				require.NoError(t, err)
+				assert.Equal(t, len(data), n)
+
+				actual, err := fs.ReadFile(fsys, "dir1/file2")
+				require.NoError(t, err)
+				assert.Equal(t, data, actual)
+
+				entries, err := fs.ReadDir(fsys, "dir1")
+				require.NoError(t, err)
+				assert.Len(t, entries, 2)
+			})
+
+			t.Run("creates new files", func(t *testing.T) {
+				fsys := fsys()
+				_, err := writefs.WriteFileAtomic(fsys, "dir4/file5", data, 0600)
+				require.NoError(t, err)
+
+				info, err := fs.Stat(fsys, "dir4/file5")
+				require.NoError(t, err)
+				assert.Equal(t, fs.FileMode(0600), info.Mode().Perm())
+			})
+		})
+	}
+
+	t.Run("refuses fsys not implementing RenameFS", func(t *testing.T) {
+		fsys := openFileOnlyFS{newTreeFS()}
+		n, err := writefs.WriteFileAtomic(fsys, "file1", data, 0644)
+		assert.Zero(t, n)
+		assert.EqualError(t, err, "WriteFileAtomic file1: invalid argument fsys: does not implement RenameFS")
+
+		actual, err := fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(actual))
+	})
+
+	t.Run("removes temporary file on failure", func(t *testing.T) {
+		fsys := failingRenameFS{newTreeFS()}
+		n, err := writefs.WriteFileAtomic(fsys, "dir4/file5", data, 0644)
+		assert.Zero(t, n)
+		assert.EqualError(t, err, "WriteFileAtomic dir4/file5: expected")
+
+		entries, err := fs.ReadDir(fsys, "dir4")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("removes temporary file when wrapped fsys can't rename", func(t *testing.T) {
+		fsys := writefs.WithHooks(openFileOnlyFS{newTreeFS()}, writefs.Hooks{})
+		n, err := writefs.WriteFileAtomic(fsys, "dir4/file5", data, 0644)
+		assert.Zero(t, n)
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+
+		entries, err := fs.ReadDir(fsys, "dir4")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		_, err := writefs.WriteFileAtomic(newTreeFS(), "/", data, 0644)
+		assert.EqualError(t, err, "WriteFileAtomic /: invalid argument name: not a valid path")
+	})
+}
@@ -0,0 +1,136 @@
+package writefs
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Capability is a set of operations supported by a file system,
+// as returned by Capabilities.
+type Capability uint
+
+// Capability constants can be combined using bitwise or.
+const (
+	// CapWritable reports that files can be created
+	// and written using OpenFile.
+	CapWritable Capability = 1 << iota
+	// CapRemove reports that files and directories can be removed.
+	CapRemove
+	// CapMkdir reports that directories can be created.
+	CapMkdir
+	// CapRename reports that files can be renamed
+	// without copying their content.
+	CapRename
+	// CapSymlink reports that symbolic links can be created.
+	CapSymlink
+	// CapChmod reports that file modes can be changed.
+	CapChmod
+	// CapChtimes reports that file times can be changed.
+	CapChtimes
+	// CapSync reports that files opened for
+	// writing can be committed to stable storage.
+	CapSync
+	// CapTruncate reports that files opened
+	// for writing can be truncated to a size.
+	CapTruncate
+	// CapSeek reports that files support io.Seeker.
+	CapSeek
+)
+
+// wrapperCaps are the capabilities preserved by
+// file systems of this package that wrap the files
+// opened for writing.
+const wrapperCaps = CapWritable | CapRemove | CapMkdir | CapRename | CapSync | CapTruncate | CapSeek
+
+// capabilityNames lists the names of
+// Capability constants, in String order.
+var capabilityNames = []struct {
+	capability Capability
+	name       string
+}{
+	{CapWritable, "Writable"},
+	{CapRemove, "Remove"},
+	{CapMkdir, "Mkdir"},
+	{CapRename, "Rename"},
+	{CapSymlink, "Symlink"},
+	{CapChmod, "Chmod"},
+	{CapChtimes, "Chtimes"},
+	{CapSync, "Sync"},
+	{CapTruncate, "Truncate"},
+	{CapSeek, "Seek"},
+}
+
+// Has reports whether c contains all the capabilities of caps.
+func (c Capability) Has(caps Capability) bool {
+	return c&caps == caps
+}
+
+// String returns the names of the capabilities
+// in c, separated by a pipe, e.g. "Writable|Remove".
+// An empty set is reported as "None".
+// Unknown bits are reported as an hexadecimal number.
+func (c Capability) String() string {
+	if c == 0 {
+		return "None"
+	}
+	var parts []string
+	for _, n := range capabilityNames {
+		if c&n.capability != 0 {
+			parts = append(parts, n.name)
+			c &^= n.capability
+		}
+	}
+	if c != 0 {
+		parts = append(parts, fmt.Sprintf("0x%x", uint(c)))
+	}
+	return strings.Join(parts, "|")
+}
+
+// CapabilitiesFS is the interface implemented by a file system
+// that describes the operations it supports.
+type CapabilitiesFS interface {
+	fs.FS
+
+	// Capabilities returns the operations supported by the file system.
+	Capabilities() Capability
+}
+
+// Capabilities returns the operations supported by fsys.
+//
+// If fsys implements CapabilitiesFS, Capabilities returns
+// the result of fsys.Capabilities. Otherwise, capabilities are
+// determined from the interfaces implemented by fsys:
+// a WriteFS is writable and, following the OpenFile conventions,
+// supports removal and creation of directories;
+// CapRename, CapSymlink, CapChmod and CapChtimes are reported
+// when fsys implements RenameFS, SymlinkFS, ChmodFS and ChtimesFS.
+// Capabilities of files can't be determined
+// this way, so they are never reported.
+//
+// Capabilities describe what fsys supports in general:
+// single operations can still fail, for example
+// because of permissions.
+func Capabilities(fsys fs.FS) Capability {
+	if fsys, ok := fsys.(CapabilitiesFS); ok {
+		return fsys.Capabilities()
+	}
+
+	var caps Capability
+	if writable(fsys) {
+		caps |= CapWritable | CapRemove | CapMkdir
+	}
+	if _, ok := fsys.(RenameFS); ok {
+		caps |= CapRename
+	}
+	if _, ok := fsys.(SymlinkFS); ok {
+		caps |= CapSymlink
+	}
+	if _, ok := fsys.(ChmodFS); ok {
+		caps |= CapChmod
+	}
+	if _, ok := fsys.(ChtimesFS); ok {
+		caps |= CapChtimes
+	}
+	return caps
+}
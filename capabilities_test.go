@@ -0,0 +1,79 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedCapsFS implements writefs.CapabilitiesFS
+type fixedCapsFS struct {
+	fstest.MapFS
+	caps writefs.Capability
+}
+
+func (fsys fixedCapsFS) Capabilities() writefs.Capability {
+	return fsys.caps
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "None", writefs.Capability(0).String())
+		assert.Equal(t, "Writable|Rename|Seek", (writefs.CapWritable | writefs.CapRename | writefs.CapSeek).String())
+		assert.Equal(t, "Truncate|0x8000", (writefs.CapTruncate | 0x8000).String())
+	})
+
+	t.Run("Has", func(t *testing.T) {
+		caps := writefs.CapWritable | writefs.CapRemove
+		assert.True(t, caps.Has(writefs.CapWritable))
+		assert.True(t, caps.Has(writefs.CapWritable|writefs.CapRemove))
+		assert.False(t, caps.Has(writefs.CapWritable|writefs.CapRename))
+	})
+
+	conventions := writefs.CapWritable | writefs.CapRemove | writefs.CapMkdir
+	meta := writefs.CapChmod | writefs.CapChtimes
+	files := writefs.CapSync | writefs.CapTruncate | writefs.CapSeek
+	for name, test := range map[string]struct {
+		fsys fs.FS
+		caps writefs.Capability
+	}{
+		"read-only fs.FS":  {fstest.MapFS{}, 0},
+		"WriteFS":          {openFileOnlyFS{newTreeFS()}, conventions},
+		"CapabilitiesFS":   {fixedCapsFS{caps: writefs.CapSymlink}, writefs.CapSymlink},
+		"MemFS":            {newTreeFS(), conventions | writefs.CapRename | meta | files},
+		"DirFS":            {writefs.DirFS(t.TempDir()), conventions | writefs.CapRename | writefs.CapSymlink | meta | files},
+		"ReadOnlyFS":       {writefs.ReadOnlyFS(newTreeFS()), writefs.CapSeek},
+		"Sub":              {mustSubWriteFS(t, newTreeDirFS(t)), conventions | writefs.CapRename | writefs.CapSymlink | meta | files},
+		"WithQuota":        {writefs.WithQuota(writefs.DirFS(t.TempDir()), -1, -1), conventions | writefs.CapRename | files},
+		"WithHooks":        {writefs.WithHooks(openFileOnlyFS{newTreeFS()}, writefs.Hooks{}), conventions},
+		"Overlay":          {writefs.Overlay(fstest.MapFS{}, writefs.DirFS(t.TempDir())), conventions | files},
+		"WithWatch on Sub": {writefs.WithWatch(mustSubWriteFS(t, newTreeFS())), conventions | writefs.CapRename | files},
+		"read-only Mount":  {newMount(t, fstest.MapFS{}, nil), 0},
+		"Mount":            {newMount(t, fstest.MapFS{}, writefs.DirFS(t.TempDir())), conventions | writefs.CapRename | files},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.caps, writefs.Capabilities(test.fsys))
+		})
+	}
+}
+
+// newMount returns a Mount with root mounted on "."
+// and, if it is not nil, data mounted on "data".
+func newMount(t *testing.T, root fs.FS, data fs.FS) *writefs.Mount {
+	m := &writefs.Mount{}
+	require.NoError(t, m.Mount(".", root))
+	if data != nil {
+		require.NoError(t, m.Mount("data", data))
+	}
+	return m
+}
+
+func mustSubWriteFS(t *testing.T, fsys writefs.WriteFS) writefs.WriteFS {
+	sub, err := writefs.Sub(fsys, "dir1")
+	require.NoError(t, err)
+	return sub
+}
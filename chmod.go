@@ -0,0 +1,107 @@
+package writefs
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// ChmodFS is the interface implemented by a file
+// system that can change the mode of its files.
+type ChmodFS interface {
+	fs.FS
+
+	// Chmod changes the mode of the named file to mode.
+	// If the file is a symbolic link, it changes
+	// the mode of the link's target.
+	// If there is an error, it will be of type *fs.PathError.
+	Chmod(name string, mode fs.FileMode) error
+}
+
+// ChownFS is the interface implemented by a file system
+// that can change the owner of its files.
+type ChownFS interface {
+	fs.FS
+
+	// Chown changes the numeric uid and gid of the named file.
+	// If the file is a symbolic link, it changes
+	// the uid and gid of the link's target.
+	// A uid or gid of -1 means to not change that value.
+	// If there is an error, it will be of type *fs.PathError.
+	Chown(name string, uid, gid int) error
+}
+
+// ChtimesFS is the interface implemented by a file system
+// that can change the access and modification times of its files.
+type ChtimesFS interface {
+	fs.FS
+
+	// Chtimes changes the access and modification
+	// times of the named file.
+	// If there is an error, it will be of type *fs.PathError.
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+}
+
+// Chmod changes the mode of the named file to mode.
+//
+// If fsys implements ChmodFS, Chmod calls fsys.Chmod.
+// Otherwise, Chmod returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Chmod(fsys fs.FS, name string, mode fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "Chmod", Path: name, Err: err}
+	}
+
+	cfs, ok := fsys.(ChmodFS)
+	if !ok {
+		return &fs.PathError{Op: "Chmod", Path: name, Err: &UnsupportedError{Interface: "ChmodFS"}}
+	}
+	if err := cfs.Chmod(name, mode); err != nil {
+		return opPathError("Chmod", name, err)
+	}
+	return nil
+}
+
+// Chown changes the numeric uid and gid of the named file.
+//
+// If fsys implements ChownFS, Chown calls fsys.Chown.
+// Otherwise, Chown returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Chown(fsys fs.FS, name string, uid, gid int) error {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "Chown", Path: name, Err: err}
+	}
+
+	cfs, ok := fsys.(ChownFS)
+	if !ok {
+		return &fs.PathError{Op: "Chown", Path: name, Err: &UnsupportedError{Interface: "ChownFS"}}
+	}
+	if err := cfs.Chown(name, uid, gid); err != nil {
+		return opPathError("Chown", name, err)
+	}
+	return nil
+}
+
+// Chtimes changes the access and modification
+// times of the named file.
+//
+// If fsys implements ChtimesFS, Chtimes calls fsys.Chtimes.
+// Otherwise, Chtimes returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Chtimes(fsys fs.FS, name string, atime time.Time, mtime time.Time) error {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "Chtimes", Path: name, Err: err}
+	}
+
+	cfs, ok := fsys.(ChtimesFS)
+	if !ok {
+		return &fs.PathError{Op: "Chtimes", Path: name, Err: &UnsupportedError{Interface: "ChtimesFS"}}
+	}
+	if err := cfs.Chtimes(name, atime, mtime); err != nil {
+		return opPathError("Chtimes", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChmod(t *testing.T) {
+	mtime := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, newFS := range map[string]func() (writefs.WriteFS, string){
+		"MemFS": func() (writefs.WriteFS, string) { return newTreeFS(), "dir1/file2" },
+		"DirFS": func() (writefs.WriteFS, string) { return newTreeDirFS(t), "dir1/file2" },
+		"Sub": func() (writefs.WriteFS, string) {
+			sub, err := writefs.Sub(newTreeFS(), "dir1")
+			require.NoError(t, err)
+			return sub, "file2"
+		},
+		"Jail": func() (writefs.WriteFS, string) {
+			jail, err := writefs.Jail(writefs.DirFS(newJailDir(t)), "jail")
+			require.NoError(t, err)
+			return jail, "inside"
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys, file := newFS()
+
+			require.NoError(t, writefs.Chmod(fsys, file, 0750))
+			require.NoError(t, writefs.Chtimes(fsys, file, mtime, mtime))
+			info, err := fs.Stat(fsys, file)
+			require.NoError(t, err)
+			assert.Equal(t, fs.FileMode(0750), info.Mode())
+			assert.True(t, mtime.Equal(info.ModTime()))
+
+			err = writefs.Chmod(fsys, "not-existent", 0750)
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			err = writefs.Chtimes(fsys, "not-existent", mtime, mtime)
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+
+	t.Run("DirFS Chown", func(t *testing.T) {
+		assert.NoError(t, writefs.Chown(newTreeDirFS(t), "file1", -1, -1))
+	})
+
+	t.Run("keeps file type", func(t *testing.T) {
+		fsys := newTreeFS()
+		require.NoError(t, writefs.Chmod(fsys, "dir1", 0700))
+		info, err := fs.Stat(fsys, "dir1")
+		require.NoError(t, err)
+		assert.Equal(t, fs.ModeDir|0700, info.Mode())
+	})
+
+	t.Run("return UnsupportedError", func(t *testing.T) {
+		fsys := fstest.MapFS{"file1": {}}
+		for _, err := range []error{
+			writefs.Chmod(fsys, "file1", 0755),
+			writefs.Chown(fsys, "file1", 0, 0),
+			writefs.Chtimes(fsys, "file1", mtime, mtime),
+			writefs.Chmod(newTreeFS(), "../file1", 0755),
+		} {
+			assert.ErrorIs(t, err, fs.ErrInvalid)
+			var perr *fs.PathError
+			assert.ErrorAs(t, err, &perr)
+		}
+		err := writefs.Chmod(fsys, "file1", 0755)
+		assert.EqualError(t, err, "Chmod file1: operation not supported: does not implement ChmodFS")
+		var uerr *writefs.UnsupportedError
+		assert.True(t, errors.As(err, &uerr))
+	})
+}
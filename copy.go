@@ -0,0 +1,157 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ExistPolicy specifies what CopyFile and
+// CopyTree do when a destination file already exists.
+type ExistPolicy int
+
+const (
+	// Overwrite replaces the content of existing files.
+	Overwrite ExistPolicy = iota
+	// Skip leaves existing files untouched.
+	Skip
+	// Fail reports an error wrapping fs.ErrExist for existing files.
+	Fail
+)
+
+// CopyOptions customizes CopyFile and CopyTree behavior.
+type CopyOptions struct {
+	// Exists specifies what to do when a
+	// destination file already exists.
+	// Overwrite is used by default.
+	Exists ExistPolicy
+}
+
+// flag returns the Flag used to open destination files.
+func (opts CopyOptions) flag() Flag {
+	if opts.Exists == Overwrite {
+		return WriteOnly | Create | Truncate
+	}
+	return WriteOnly | Create | Exclusive
+}
+
+// CopyFile copies the regular file srcName of src file system
+// to dstName on dst file system.
+// If dstName does not exist, it is created with the same
+// permissions of srcName. If it exists, opts.Exists specifies
+// whether to overwrite it, skip it or fail.
+// When dst implements ChmodFS, the permissions of srcName are
+// also applied to overwritten files, and are not restricted
+// by the umask of the process; otherwise, overwritten files
+// keep their permissions.
+//
+// If there is an error, it will be of type *fs.PathError.
+func CopyFile(dst WriteFS, dstName string, src fs.FS, srcName string, opts CopyOptions) error {
+	if err := copyFile(dst, dstName, src, srcName, opts); err != nil {
+		return opPathError("CopyFile", srcName, err)
+	}
+	return nil
+}
+
+func copyFile(dst WriteFS, dstName string, src fs.FS, srcName string, opts CopyOptions) (err error) {
+	in, err := src.Open(srcName)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		err := fmt.Errorf("%w srcName: not a regular file", fs.ErrInvalid)
+		return &fs.PathError{Op: "CopyFile", Path: srcName, Err: err}
+	}
+
+	out, err := OpenFile(dst, dstName, opts.flag(), info.Mode().Perm())
+	if errors.Is(err, fs.ErrExist) && opts.Exists == Skip {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		errClose := out.Close()
+		if errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return &fs.PathError{Op: "CopyFile", Path: dstName, Err: err}
+	}
+	if _, ok := dst.(ChmodFS); ok {
+		return Chmod(dst, dstName, info.Mode().Perm())
+	}
+	return nil
+}
+
+// CopyTree copies the directory srcDir of src file system,
+// and all its content, to dstDir on dst file system.
+//
+// Directories are created using the OpenFile convention,
+// with the same permissions of source ones.
+// Files are copied using CopyFile, following opts.
+//
+// CopyTree copies everything it can: if errors occur on
+// single entries, it goes on and finally returns a *TreeError
+// that records all of them.
+// If srcDir itself cannot be read, the error
+// is of type *fs.PathError.
+//
+// When src and dst are the same file system,
+// dstDir must not be inside srcDir.
+func CopyTree(dst WriteFS, dstDir string, src fs.FS, srcDir string, opts CopyOptions) error {
+	if !fs.ValidPath(dstDir) {
+		err := fmt.Errorf("%w dstDir: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "CopyTree", Path: dstDir, Err: err}
+	}
+	if _, err := fs.Stat(src, srcDir); err != nil {
+		return opPathError("CopyTree", srcDir, err)
+	}
+
+	var errs []error
+	fs.WalkDir(src, srcDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, opPathError("CopyTree", name, err))
+			return nil
+		}
+
+		rel := name
+		if srcDir != "." {
+			rel = strings.TrimPrefix(strings.TrimPrefix(name, srcDir), "/")
+		}
+		target := path.Join(dstDir, rel)
+
+		if !d.IsDir() {
+			if err := CopyFile(dst, target, src, name, opts); err != nil {
+				errs = append(errs, err)
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err == nil {
+			_, err = OpenFile(dst, target, Create, info.Mode().Perm()|fs.ModeDir)
+		}
+		if err != nil {
+			errs = append(errs, opPathError("CopyTree", target, err))
+			return fs.SkipDir
+		}
+		return nil
+	})
+
+	if len(errs) > 0 {
+		return &TreeError{Op: "CopyTree", Path: srcDir, Errs: errs}
+	}
+	return nil
+}
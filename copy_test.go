@@ -0,0 +1,135 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFile(t *testing.T) {
+	src := fstest.MapFS{
+		"file1": &fstest.MapFile{Data: []byte("copied"), Mode: 0600},
+	}
+
+	t.Run("copies content and mode", func(t *testing.T) {
+		dst := newTreeFS()
+		require.NoError(t, writefs.CopyFile(dst, "dir4/file5", src, "file1", writefs.CopyOptions{}))
+
+		data, err := fs.ReadFile(dst, "dir4/file5")
+		require.NoError(t, err)
+		assert.Equal(t, "copied", string(data))
+		info, err := fs.Stat(dst, "dir4/file5")
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0600), info.Mode())
+	})
+
+	t.Run("overwrites existing files", func(t *testing.T) {
+		dst := newTreeFS()
+		require.NoError(t, writefs.CopyFile(dst, "file1", src, "file1", writefs.CopyOptions{Exists: writefs.Overwrite}))
+		data, err := fs.ReadFile(dst, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "copied", string(data))
+	})
+
+	t.Run("preserves mode of overwritten files", func(t *testing.T) {
+		dst := writefs.DirFS(t.TempDir())
+		_, err := writefs.WriteFile(dst, "file1", []byte("old"))
+		require.NoError(t, err)
+		require.NoError(t, writefs.Chmod(dst, "file1", 0600))
+
+		src := fstest.MapFS{"file1": &fstest.MapFile{Data: []byte("copied"), Mode: 0755}}
+		require.NoError(t, writefs.CopyFile(dst, "file1", src, "file1", writefs.CopyOptions{}))
+		info, err := fs.Stat(dst, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0755), info.Mode())
+	})
+
+	t.Run("skips existing files", func(t *testing.T) {
+		dst := newTreeFS()
+		require.NoError(t, writefs.CopyFile(dst, "file1", src, "file1", writefs.CopyOptions{Exists: writefs.Skip}))
+		data, err := fs.ReadFile(dst, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+	})
+
+	t.Run("fails on existing files", func(t *testing.T) {
+		dst := newTreeFS()
+		err := writefs.CopyFile(dst, "file1", src, "file1", writefs.CopyOptions{Exists: writefs.Fail})
+		assert.ErrorIs(t, err, fs.ErrExist)
+		var perr *fs.PathError
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, "CopyFile", perr.Op)
+	})
+
+	t.Run("refuses directories", func(t *testing.T) {
+		err := writefs.CopyFile(newTreeFS(), "dir5", fixtureFS, "dir1", writefs.CopyOptions{})
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+}
+
+func TestCopyTree(t *testing.T) {
+	t.Run("copies whole trees", func(t *testing.T) {
+		dst := writefs.NewMemFS(nil)
+		require.NoError(t, writefs.CopyTree(dst, "copy", fixtureFS, "dir1", writefs.CopyOptions{}))
+
+		assert.NoError(t, fstest.TestFS(mustSub(t, dst, "copy"),
+			"dir2/file3.txt.template",
+			"dir3/file4.template",
+			"file2",
+			"vars/test.template",
+		))
+
+		expected, err := fs.ReadFile(fixtureFS, "dir1/file2")
+		require.NoError(t, err)
+		actual, err := fs.ReadFile(dst, "copy/file2")
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("copies root directory", func(t *testing.T) {
+		dst := writefs.NewMemFS(nil)
+		require.NoError(t, writefs.CopyTree(dst, ".", fixtureFS, ".", writefs.CopyOptions{}))
+		_, err := fs.Stat(dst, "dir1/dir2/file3.txt.template")
+		assert.NoError(t, err)
+	})
+
+	t.Run("reports all errors", func(t *testing.T) {
+		dst := newTreeFS()
+		src := fstest.MapFS{
+			"dir1/file2":      &fstest.MapFile{Data: []byte("copied")},
+			"dir1/dir2/file3": &fstest.MapFile{Data: []byte("copied")},
+			"dir1/file6":      &fstest.MapFile{Data: []byte("copied")},
+		}
+		err := writefs.CopyTree(dst, ".", src, ".", writefs.CopyOptions{Exists: writefs.Fail})
+		require.Error(t, err)
+
+		var terr *writefs.TreeError
+		require.ErrorAs(t, err, &terr)
+		assert.Len(t, terr.Errs, 2)
+		assert.ErrorIs(t, err, fs.ErrExist)
+		var perr *fs.PathError
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, "CopyFile", perr.Op)
+
+		data, err := fs.ReadFile(dst, "dir1/file6")
+		require.NoError(t, err)
+		assert.Equal(t, "copied", string(data))
+	})
+
+	t.Run("return PathError for missing srcDir", func(t *testing.T) {
+		err := writefs.CopyTree(newTreeFS(), ".", fixtureFS, "notexists", writefs.CopyOptions{})
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, ok := err.(*fs.PathError)
+		assert.True(t, ok)
+	})
+}
+
+func mustSub(t *testing.T, fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	require.NoError(t, err)
+	return sub
+}
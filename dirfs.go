@@ -0,0 +1,419 @@
+package writefs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxSymlinks is the maximum number of symbolic links
+// followed while resolving a single path.
+const maxSymlinks = 255
+
+// DirFS returns a WriteFS for the tree of files rooted at the directory dir.
+// It is the writable counterpart of os.DirFS.
+//
+// The returned file system implements the OpenFile conventions documented
+// on WriteFS: Create with fs.ModeDir in perm creates a directory
+// and any missing parent, Truncate without WriteOnly nor ReadWrite
+// deletes the file or directory recursively.
+// It also implements fs.StatFS, fs.ReadDirFS, RemoveFS, MkDirFS, RenameFS,
+// ChmodFS, ChownFS, ChtimesFS, SymlinkFS and LinkFS.
+//
+// Paths are resolved one component at a time: any path or symbolic
+// link that would escape dir is refused with a *fs.PathError
+// wrapping fs.ErrPermission.
+func DirFS(dir string) WriteFS {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+var (
+	_ WriteFS        = dirFS("")
+	_ fs.StatFS      = dirFS("")
+	_ fs.ReadDirFS   = dirFS("")
+	_ RemoveFS       = dirFS("")
+	_ MkDirFS        = dirFS("")
+	_ RenameFS       = dirFS("")
+	_ linkFS         = dirFS("")
+	_ CapabilitiesFS = dirFS("")
+)
+
+// Open implements fs.FS
+func (dir dirFS) Open(name string) (fs.File, error) {
+	full, err := dir.resolve("Open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, dirPathError("Open", name, err)
+	}
+	return f, nil
+}
+
+// Stat implements fs.StatFS
+func (dir dirFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := dir.resolve("Stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, dirPathError("Stat", name, err)
+	}
+	return info, nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (dir dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := dir.resolve("ReadDir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, dirPathError("ReadDir", name, err)
+	}
+	return entries, nil
+}
+
+// OpenFile implements WriteFS
+func (dir dirFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	if isMkdir(flag, perm) {
+		return nil, dir.mkdir(name, flag, perm)
+	}
+	if isRemove(flag) {
+		return nil, dir.remove(name)
+	}
+
+	full, err := dir.resolve("OpenFile", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(full, int(flag), perm&fs.ModePerm)
+	if err != nil {
+		return nil, dirPathError("OpenFile", name, err)
+	}
+	return f, nil
+}
+
+// Remove implements RemoveFS
+func (dir dirFS) Remove(name string) error {
+	if name == "." {
+		return &fs.PathError{Op: "Remove", Path: name, Err: fs.ErrInvalid}
+	}
+	full, err := dir.resolve("Remove", name, false)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil {
+		return dirPathError("Remove", name, err)
+	}
+	return nil
+}
+
+// MkDir implements MkDirFS
+func (dir dirFS) MkDir(name string, perm fs.FileMode) error {
+	full, err := dir.resolve("MkDir", name, true)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(full, perm&fs.ModePerm); err != nil {
+		return dirPathError("MkDir", name, err)
+	}
+	return nil
+}
+
+// Rename implements RenameFS
+func (dir dirFS) Rename(oldname, newname string) error {
+	if oldname == "." || newname == "." {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: fs.ErrInvalid}
+	}
+	oldFull, err := dir.resolve("Rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	newFull, err := dir.resolve("Rename", newname, false)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(oldFull, newFull); err != nil {
+		var lerr *os.LinkError
+		if errors.As(err, &lerr) {
+			err = lerr.Err
+		}
+		return dirPathError("Rename", oldname, err)
+	}
+	return nil
+}
+
+// Symlink implements SymlinkFS.
+// Targets pointing outside of dir are refused
+// with a *fs.PathError wrapping fs.ErrInvalid.
+func (dir dirFS) Symlink(oldname, newname string) error {
+	if err := checkLinkTarget(dir, ".", oldname, newname); err != nil {
+		return err
+	}
+	full, err := dir.resolve("Symlink", newname, false)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(filepath.FromSlash(oldname), full); err != nil {
+		return dirPathError("Symlink", newname, err)
+	}
+	return nil
+}
+
+// Link implements LinkFS
+func (dir dirFS) Link(oldname, newname string) error {
+	if oldname == "." || newname == "." {
+		return &fs.PathError{Op: "Link", Path: oldname, Err: fs.ErrInvalid}
+	}
+	oldFull, err := dir.resolve("Link", oldname, false)
+	if err != nil {
+		return err
+	}
+	newFull, err := dir.resolve("Link", newname, false)
+	if err != nil {
+		return err
+	}
+	if err := os.Link(oldFull, newFull); err != nil {
+		return dirPathError("Link", oldname, err)
+	}
+	return nil
+}
+
+// Lstat implements SymlinkFS.
+// If the file is a symbolic link, the returned fs.FileInfo
+// describes the link, not its target.
+func (dir dirFS) Lstat(name string) (fs.FileInfo, error) {
+	full, err := dir.resolve("Lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(full)
+	if err != nil {
+		return nil, dirPathError("Lstat", name, err)
+	}
+	return info, nil
+}
+
+// Readlink implements SymlinkFS.
+// The target is returned using forward slashes.
+func (dir dirFS) Readlink(name string) (string, error) {
+	full, err := dir.resolve("Readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	target, err := os.Readlink(full)
+	if err != nil {
+		return "", dirPathError("Readlink", name, err)
+	}
+	return filepath.ToSlash(target), nil
+}
+
+// Capabilities implements CapabilitiesFS
+func (dir dirFS) Capabilities() Capability {
+	return CapWritable | CapRemove | CapMkdir | CapRename | CapSymlink | CapChmod | CapChtimes | CapSync | CapTruncate | CapSeek
+}
+
+// Chmod implements ChmodFS
+func (dir dirFS) Chmod(name string, mode fs.FileMode) error {
+	full, err := dir.resolve("Chmod", name, true)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(full, mode); err != nil {
+		return dirPathError("Chmod", name, err)
+	}
+	return nil
+}
+
+// Chown implements ChownFS
+func (dir dirFS) Chown(name string, uid, gid int) error {
+	full, err := dir.resolve("Chown", name, true)
+	if err != nil {
+		return err
+	}
+	if err := os.Chown(full, uid, gid); err != nil {
+		return dirPathError("Chown", name, err)
+	}
+	return nil
+}
+
+// Chtimes implements ChtimesFS
+func (dir dirFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	full, err := dir.resolve("Chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	if err := os.Chtimes(full, atime, mtime); err != nil {
+		return dirPathError("Chtimes", name, err)
+	}
+	return nil
+}
+
+// mkdir creates directory name and any missing parent.
+// When flag contains Exclusive, name itself must not exist.
+func (dir dirFS) mkdir(name string, flag Flag, perm fs.FileMode) error {
+	full, err := dir.resolve("OpenFile", name, true)
+	if err != nil {
+		return err
+	}
+
+	if flag&Exclusive == 0 {
+		err = os.MkdirAll(full, perm&fs.ModePerm)
+	} else if err = os.MkdirAll(filepath.Dir(full), perm&fs.ModePerm); err == nil {
+		err = os.Mkdir(full, perm&fs.ModePerm)
+	}
+	if err != nil {
+		return dirPathError("OpenFile", name, err)
+	}
+	return nil
+}
+
+// remove deletes name and, if it is a directory, all its content.
+// Symbolic links are removed, never their target.
+func (dir dirFS) remove(name string) error {
+	if name == "." {
+		return &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+	full, err := dir.resolve("OpenFile", name, false)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(full); err != nil {
+		return dirPathError("OpenFile", name, err)
+	}
+	if err := os.RemoveAll(full); err != nil {
+		return dirPathError("OpenFile", name, err)
+	}
+	return nil
+}
+
+// resolve converts name to a path on the host file system.
+// Any symbolic link found along name is followed and checked
+// to not escape dir. When followLast is false, the last element
+// of name is not followed even if it is a symbolic link.
+// Path elements that does not exist yet are joined lexically.
+func (dir dirFS) resolve(op string, name string, followLast bool) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	root, err := filepath.EvalSymlinks(string(dir))
+	if err != nil {
+		return "", dirPathError(op, name, err)
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", dirPathError(op, name, err)
+	}
+
+	var parts []string
+	if name != "." {
+		parts = strings.Split(name, "/")
+	}
+
+	resolved := root
+	links := 0
+	missing := false
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if resolved == root {
+				return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+			}
+			resolved = filepath.Dir(resolved)
+			missing = false
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		if missing || len(parts) == 0 && !followLast {
+			resolved = next
+			continue
+		}
+
+		info, err := os.Lstat(next)
+		if errors.Is(err, fs.ErrNotExist) {
+			// elements after a missing one cannot be links,
+			// but ".." must still be checked
+			missing = true
+			resolved = next
+			continue
+		}
+		if err != nil {
+			return "", dirPathError(op, name, err)
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", dirPathError(op, name, err)
+		}
+		if filepath.IsAbs(target) {
+			rel, err := filepath.Rel(root, target)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+			}
+			target = rel
+			resolved = root
+		}
+		parts = append(strings.Split(filepath.ToSlash(target), "/"), parts...)
+	}
+
+	return resolved, nil
+}
+
+// dirPathError returns a *fs.PathError that
+// reports name instead of the host path
+// contained in errors returned by package os.
+// Errors that match a sentinel error of this
+// package are classified accordingly.
+func dirPathError(op string, name string, err error) error {
+	var perr *fs.PathError
+	var lerr *os.LinkError
+	if errors.As(err, &perr) {
+		err = perr.Err
+	} else if errors.As(err, &lerr) {
+		err = lerr.Err
+	}
+	if kind := errnoKind(err); kind != nil {
+		err = &kindError{kind: kind, err: err}
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// errnoKind returns the sentinel error
+// matching err, or nil if there is none.
+func errnoKind(err error) error {
+	switch {
+	case errors.Is(err, syscall.ENOTEMPTY):
+		return ErrNotEmpty
+	case errors.Is(err, syscall.EISDIR):
+		return ErrIsDir
+	case errors.Is(err, syscall.ENOTDIR):
+		return ErrNotDir
+	case errors.Is(err, syscall.EXDEV):
+		return ErrCrossDevice
+	}
+	return nil
+}
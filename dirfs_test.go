@@ -0,0 +1,129 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirFS(t *testing.T) {
+	data := []byte("ciao")
+
+	t.Run("writes and reads files", func(t *testing.T) {
+		root := t.TempDir()
+		fsys := writefs.DirFS(root)
+
+		n, err := writefs.WriteFile(fsys, "file1", data)
+		require.NoError(t, err)
+		assert.Equal(t, len(data), n)
+
+		actual, err := os.ReadFile(filepath.Join(root, "file1"))
+		require.NoError(t, err)
+		assert.Equal(t, data, actual)
+
+		actual, err = fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, data, actual)
+
+		assert.NoError(t, fstest.TestFS(fsys, "file1"))
+	})
+
+	t.Run("creates directories recursively", func(t *testing.T) {
+		root := t.TempDir()
+		fsys := writefs.DirFS(root)
+
+		f, err := fsys.OpenFile("dir1/dir2", writefs.Create, fs.ModeDir|0755)
+		require.NoError(t, err)
+		assert.Nil(t, f)
+
+		info, err := fs.Stat(fsys, "dir1/dir2")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+
+		_, err = fsys.OpenFile("dir1/dir2", writefs.Create|writefs.Exclusive, fs.ModeDir|0755)
+		assert.ErrorIs(t, err, fs.ErrExist)
+	})
+
+	t.Run("deletes recursively", func(t *testing.T) {
+		root := t.TempDir()
+		fsys := writefs.DirFS(root)
+
+		_, err := writefs.WriteFile(fsys, "file1", data)
+		require.NoError(t, err)
+		_, err = fsys.OpenFile("dir1/dir2", writefs.Create, fs.ModeDir|0755)
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(fsys, "dir1/dir2/file2", data)
+		require.NoError(t, err)
+
+		f, err := fsys.OpenFile("dir1", writefs.Truncate, 0)
+		require.NoError(t, err)
+		assert.Nil(t, f)
+
+		entries, err := fs.ReadDir(fsys, ".")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "file1", entries[0].Name())
+
+		_, err = fsys.OpenFile("dir1", writefs.Truncate, 0)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = fsys.OpenFile(".", writefs.Truncate, 0)
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+
+	t.Run("reports errors with relative paths", func(t *testing.T) {
+		fsys := writefs.DirFS(t.TempDir())
+
+		_, err := fsys.Open("notexists")
+		require.Error(t, err)
+		perr, ok := err.(*fs.PathError)
+		require.True(t, ok)
+		assert.Equal(t, "Open", perr.Op)
+		assert.Equal(t, "notexists", perr.Path)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = fsys.Open("../notexists")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+
+	t.Run("refuses symlinks that escape root", func(t *testing.T) {
+		outside := t.TempDir()
+		root := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+		require.NoError(t, os.Symlink("../..", filepath.Join(root, "relative")))
+		require.NoError(t, os.Mkdir(filepath.Join(root, "dir1"), 0755))
+		require.NoError(t, os.Symlink("../dir1", filepath.Join(root, "dir1", "inside")))
+
+		fsys := writefs.DirFS(root)
+
+		_, err := writefs.WriteFile(fsys, "escape/file1", data)
+		assert.ErrorIs(t, err, fs.ErrPermission)
+		_, err = os.Stat(filepath.Join(outside, "file1"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = fsys.Open("relative/file1")
+		assert.ErrorIs(t, err, fs.ErrPermission)
+
+		require.NoError(t, os.Symlink("missing/../../..", filepath.Join(root, "throughmissing")))
+		_, err = writefs.WriteFile(fsys, "throughmissing/file1", data)
+		assert.ErrorIs(t, err, fs.ErrPermission)
+
+		_, err = writefs.WriteFile(fsys, "dir1/inside/file1", data)
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(root, "dir1", "file1"))
+		assert.NoError(t, err)
+
+		_, err = fsys.OpenFile("escape", writefs.Truncate, 0)
+		assert.NoError(t, err)
+		_, err = os.Stat(outside)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,114 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Sentinel errors returned, wrapped in a *fs.PathError, by functions
+// and file systems of this package. Each of them wraps the fs error
+// callers checked before its introduction, so that both
+// errors.Is(err, ErrNotEmpty) and errors.Is(err, fs.ErrExist) report true.
+var (
+	// ErrNotWritable is returned when a write operation is
+	// requested on a file system that does not implement WriteFS.
+	ErrNotWritable = fmt.Errorf("%w fsys: does not implement WriteFS", fs.ErrInvalid)
+	// ErrReadOnlyFile is returned when writing to a file
+	// that does not support it, such as ReadOnlyWriteFile.
+	ErrReadOnlyFile = fmt.Errorf("%w file: does not support write", fs.ErrInvalid)
+	// ErrNotEmpty is returned when removing or
+	// replacing a directory that is not empty.
+	ErrNotEmpty = fmt.Errorf("%w directory: not empty", fs.ErrExist)
+	// ErrIsDir is returned when an operation that
+	// requires a file is requested on a directory.
+	ErrIsDir = fmt.Errorf("%w file: is a directory", fs.ErrInvalid)
+	// ErrNotDir is returned when an operation that
+	// requires a directory is requested on a file.
+	ErrNotDir = fmt.Errorf("%w file: not a directory", fs.ErrInvalid)
+	// ErrCrossDevice is returned by Rename implementations that
+	// cannot move files between different devices or file systems.
+	// Rename function handles it falling back to a copy.
+	ErrCrossDevice = fmt.Errorf("%w rename: across devices", fs.ErrInvalid)
+)
+
+// kindError is an error caused by err, classified
+// as kind, one of the sentinel errors of this package.
+// errors.Is reports true for both of them.
+type kindError struct {
+	kind error
+	err  error
+}
+
+// Error implements error interface
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the kind of the error.
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// Is reports whether the cause of the error matches target.
+func (e *kindError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// As finds the first error in the cause
+// of the error chain that matches target.
+func (e *kindError) As(target interface{}) bool {
+	return errors.As(e.err, target)
+}
+
+// errUnsupported is returned by the optional methods of
+// wrapper file systems when the wrapped file system does
+// not support the operation. Package functions handle it
+// falling back to OpenFile conventions, as they do for
+// file systems that do not implement the optional interface.
+var errUnsupported = fmt.Errorf("%w operation: not supported", fs.ErrInvalid)
+
+// TreeError records the errors that occurred
+// on single entries while an operation processed
+// a tree of files.
+//
+// errors.Is and errors.As reports true for TreeError
+// when they would for any of the recorded errors.
+type TreeError struct {
+	Op   string
+	Path string
+	Errs []error
+}
+
+// Error implements error interface
+func (e *TreeError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%s %s: %d errors:\n\t%s", e.Op, e.Path, len(e.Errs), strings.Join(msgs, "\n\t"))
+}
+
+// Is reports whether any of the recorded
+// errors matches target.
+func (e *TreeError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first recorded error that
+// matches target, and if so, sets target
+// to that error value and returns true.
+func (e *TreeError) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
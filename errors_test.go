@@ -0,0 +1,104 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeError(t *testing.T) {
+	err := &writefs.TreeError{
+		Op:   "CopyTree",
+		Path: "dir1",
+		Errs: []error{
+			errors.New("expected"),
+			&fs.PathError{Op: "OpenFile", Path: "dir1/file2", Err: fs.ErrExist},
+		},
+	}
+
+	assert.EqualError(t, err, "CopyTree dir1: 2 errors:\n\texpected\n\tOpenFile dir1/file2: file already exists")
+	assert.ErrorIs(t, err, fs.ErrExist)
+	assert.False(t, errors.Is(err, fs.ErrNotExist))
+
+	var perr *fs.PathError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "dir1/file2", perr.Path)
+}
+
+func TestSentinelErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		op       func(fsys writefs.WriteFS) error
+		sentinel error
+		fsErr    error
+	}{
+		"ErrNotWritable": {
+			func(writefs.WriteFS) error {
+				_, err := writefs.WriteFile(fstest.MapFS{}, "file1", []byte("hello"))
+				return err
+			},
+			writefs.ErrNotWritable, fs.ErrInvalid,
+		},
+		"ErrNotEmpty": {
+			func(fsys writefs.WriteFS) error { return writefs.Remove(fsys, "dir1") },
+			writefs.ErrNotEmpty, fs.ErrExist,
+		},
+		"ErrIsDir": {
+			func(fsys writefs.WriteFS) error {
+				_, err := writefs.WriteFile(fsys, "dir1", []byte("hello"))
+				return err
+			},
+			writefs.ErrIsDir, fs.ErrInvalid,
+		},
+		"ErrNotDir": {
+			func(fsys writefs.WriteFS) error { return writefs.MkdirAll(fsys, "file1/dir5", 0755) },
+			writefs.ErrNotDir, fs.ErrInvalid,
+		},
+		"ErrNotDir from MkDir": {
+			func(fsys writefs.WriteFS) error { return writefs.MkDir(fsys, "file1/dir5", 0755) },
+			writefs.ErrNotDir, fs.ErrInvalid,
+		},
+	} {
+		for fsName, fsys := range map[string]writefs.WriteFS{
+			"MemFS": newTreeFS(),
+			"DirFS": newTreeDirFS(t),
+		} {
+			t.Run(name+" from "+fsName, func(t *testing.T) {
+				err := test.op(fsys)
+				assert.ErrorIs(t, err, test.sentinel)
+				assert.ErrorIs(t, err, test.fsErr)
+				var perr *fs.PathError
+				assert.ErrorAs(t, err, &perr)
+			})
+		}
+	}
+
+	t.Run("ErrReadOnlyFile from MemFS", func(t *testing.T) {
+		file, err := writefs.OpenFile(newTreeFS(), "file1", writefs.ReadOnly, 0)
+		require.NoError(t, err)
+		defer file.Close()
+		_, err = file.Write([]byte("hello"))
+		assert.ErrorIs(t, err, writefs.ErrReadOnlyFile)
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+
+	t.Run("ReadOnlyWriteFile.Write", func(t *testing.T) {
+		file, err := fstest.MapFS{"file1": {}}.Open("file1")
+		require.NoError(t, err)
+		_, err = writefs.ReadOnlyWriteFile{File: file}.Write([]byte("hello"))
+		assert.EqualError(t, err, "Write file1: invalid argument file: does not support write")
+		assert.ErrorIs(t, err, writefs.ErrReadOnlyFile)
+	})
+
+	t.Run("keeps syscall errors", func(t *testing.T) {
+		err := writefs.Remove(newTreeDirFS(t), "dir1")
+		var errno syscall.Errno
+		require.ErrorAs(t, err, &errno)
+		assert.Equal(t, syscall.ENOTEMPTY, errno)
+	})
+}
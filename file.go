@@ -0,0 +1,139 @@
+package writefs
+
+import (
+	"io"
+	"io/fs"
+)
+
+// SeekWriter is the interface implemented by a
+// FileWriter that supports io.Seeker.
+type SeekWriter interface {
+	FileWriter
+	io.Seeker
+}
+
+// WriterAtFile is the interface implemented by a
+// FileWriter that supports writes at arbitrary offsets.
+type WriterAtFile interface {
+	FileWriter
+	io.WriterAt
+}
+
+// TruncaterFile is the interface implemented by
+// a FileWriter that can change its size.
+type TruncaterFile interface {
+	FileWriter
+
+	// Truncate changes the size of the file.
+	// It does not change the offset.
+	Truncate(size int64) error
+}
+
+// SyncerFile is the interface implemented by a FileWriter
+// that can commit its content to stable storage.
+type SyncerFile interface {
+	FileWriter
+
+	// Sync commits the content of the file to stable storage.
+	Sync() error
+}
+
+// UnsupportedError is returned, wrapped in a *fs.PathError,
+// when an operation requires an optional interface not
+// implemented by a file or a file system.
+// errors.Is reports true for it and fs.ErrInvalid.
+type UnsupportedError struct {
+	// Interface is the name of the missing interface.
+	Interface string
+}
+
+// Error implements error interface
+func (e *UnsupportedError) Error() string {
+	return "operation not supported: does not implement " + e.Interface
+}
+
+// Unwrap returns an error wrapping fs.ErrInvalid.
+func (e *UnsupportedError) Unwrap() error {
+	return errUnsupported
+}
+
+// unsupportedFile returns a *fs.PathError wrapping an
+// UnsupportedError for op on f, missing interface iface.
+func unsupportedFile(op string, f fs.File, iface string) error {
+	var name string
+	if info, err := f.Stat(); err == nil {
+		name = info.Name()
+	}
+	return &fs.PathError{Op: op, Path: name, Err: &UnsupportedError{Interface: iface}}
+}
+
+// syncFile calls Sync on file f, opened by a wrapper
+// as name, or returns an UnsupportedError.
+func syncFile(f FileWriter, name string) error {
+	s, ok := f.(SyncerFile)
+	if !ok {
+		return &fs.PathError{Op: "Sync", Path: name, Err: &UnsupportedError{Interface: "SyncerFile"}}
+	}
+	return s.Sync()
+}
+
+// truncateFile calls Truncate on file f, opened by a
+// wrapper as name, or returns an UnsupportedError.
+func truncateFile(f FileWriter, name string, size int64) error {
+	t, ok := f.(TruncaterFile)
+	if !ok {
+		return &fs.PathError{Op: "Truncate", Path: name, Err: &UnsupportedError{Interface: "TruncaterFile"}}
+	}
+	return t.Truncate(size)
+}
+
+// seekFile calls Seek on file f, opened by a wrapper
+// as name, or returns an UnsupportedError.
+func seekFile(f FileWriter, name string, offset int64, whence int) (int64, error) {
+	s, ok := f.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "Seek", Path: name, Err: &UnsupportedError{Interface: "io.Seeker"}}
+	}
+	return s.Seek(offset, whence)
+}
+
+// Sync commits the content of f to stable storage.
+//
+// If f implements SyncerFile, Sync calls f.Sync.
+// Otherwise, Sync returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Sync(f FileWriter) error {
+	s, ok := f.(SyncerFile)
+	if !ok {
+		return unsupportedFile("Sync", f, "SyncerFile")
+	}
+	return s.Sync()
+}
+
+// TruncateFile changes the size of f.
+// It does not change the offset.
+//
+// If f implements TruncaterFile, TruncateFile calls f.Truncate.
+// Otherwise, TruncateFile returns a *fs.PathError
+// wrapping an UnsupportedError.
+func TruncateFile(f FileWriter, size int64) error {
+	t, ok := f.(TruncaterFile)
+	if !ok {
+		return unsupportedFile("Truncate", f, "TruncaterFile")
+	}
+	return t.Truncate(size)
+}
+
+// WriteAt writes p to f starting at offset off.
+// It does not change the offset.
+//
+// If f implements WriterAtFile, WriteAt calls f.WriteAt.
+// Otherwise, WriteAt returns a *fs.PathError
+// wrapping an UnsupportedError.
+func WriteAt(f FileWriter, p []byte, off int64) (int, error) {
+	w, ok := f.(WriterAtFile)
+	if !ok {
+		return 0, unsupportedFile("WriteAt", f, "WriterAtFile")
+	}
+	return w.WriteAt(p, off)
+}
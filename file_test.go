@@ -0,0 +1,114 @@
+package writefs_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	mockfs "github.com/parrogo/writefs/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noSeekFS is a WriteFS whose
+// files do not implement io.Seeker.
+type noSeekFS struct {
+	fstest.MapFS
+}
+
+func (fsys noSeekFS) OpenFile(name string, flag writefs.Flag, perm fs.FileMode) (writefs.FileWriter, error) {
+	f, err := fsys.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return writefs.ReadOnlyWriteFile{File: f}, nil
+}
+
+func TestFileHelpers(t *testing.T) {
+	for name, newFS := range map[string]func() writefs.WriteFS{
+		"MemFS":     func() writefs.WriteFS { return newTreeFS() },
+		"DirFS":     func() writefs.WriteFS { return newTreeDirFS(t) },
+		"WithQuota": func() writefs.WriteFS { return writefs.WithQuota(newTreeFS(), -1, -1) },
+		"WithHooks": func() writefs.WriteFS { return writefs.WithHooks(newTreeFS(), writefs.Hooks{}) },
+		"WithWatch": func() writefs.WriteFS { return writefs.WithWatch(newTreeFS()) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := newFS()
+			file, err := writefs.OpenFile(fsys, "file1", writefs.ReadWrite, 0)
+			require.NoError(t, err)
+
+			n, err := writefs.WriteAt(file, []byte("AO"), 2)
+			require.NoError(t, err)
+			assert.Equal(t, 2, n)
+			n, err = writefs.WriteAt(file, []byte("!"), 6)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			require.NoError(t, writefs.Sync(file))
+
+			data, err := fs.ReadFile(fsys, "file1")
+			require.NoError(t, err)
+			assert.Equal(t, "ciAO\x00\x00!", string(data))
+
+			require.NoError(t, writefs.TruncateFile(file, 3))
+			_, err = file.Write([]byte("x"))
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+
+			data, err = fs.ReadFile(fsys, "file1")
+			require.NoError(t, err)
+			assert.Equal(t, "xiA", string(data))
+		})
+	}
+
+	t.Run("forward calls", func(t *testing.T) {
+		file := &mockfs.FileWriter{}
+		file.On("WriteAt", []byte("hello"), int64(4)).Return(5, nil)
+		file.On("Truncate", int64(4)).Return(nil)
+		file.On("Sync").Return(nil)
+
+		n, err := writefs.WriteAt(file, []byte("hello"), 4)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, n)
+		assert.NoError(t, writefs.TruncateFile(file, 4))
+		assert.NoError(t, writefs.Sync(file))
+		file.AssertExpectations(t)
+	})
+
+	for name, wrap := range map[string]func(fsys writefs.WriteFS) writefs.WriteFS{
+		"WithQuota": func(fsys writefs.WriteFS) writefs.WriteFS { return writefs.WithQuota(fsys, -1, -1) },
+		"WithHooks": func(fsys writefs.WriteFS) writefs.WriteFS { return writefs.WithHooks(fsys, writefs.Hooks{}) },
+		"WithWatch": func(fsys writefs.WriteFS) writefs.WriteFS { return writefs.WithWatch(fsys) },
+	} {
+		t.Run(name+" Seek returns UnsupportedError", func(t *testing.T) {
+			fsys := wrap(noSeekFS{fstest.MapFS{"file1": {}}})
+			file, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly, 0)
+			require.NoError(t, err)
+			defer file.Close()
+
+			_, err = file.(io.Seeker).Seek(0, io.SeekStart)
+			assert.EqualError(t, err, "Seek file1: operation not supported: does not implement io.Seeker")
+			var uerr *writefs.UnsupportedError
+			assert.True(t, errors.As(err, &uerr))
+			assert.ErrorIs(t, err, fs.ErrInvalid)
+		})
+	}
+
+	t.Run("return UnsupportedError", func(t *testing.T) {
+		f, err := fstest.MapFS{"file1": {}}.Open("file1")
+		require.NoError(t, err)
+		file := writefs.ReadOnlyWriteFile{File: f}
+
+		_, err = writefs.WriteAt(file, []byte("hello"), 0)
+		assert.EqualError(t, err, "WriteAt file1: operation not supported: does not implement WriterAtFile")
+		for _, err := range []error{err, writefs.TruncateFile(file, 0), writefs.Sync(file)} {
+			var uerr *writefs.UnsupportedError
+			assert.True(t, errors.As(err, &uerr))
+			assert.ErrorIs(t, err, fs.ErrInvalid)
+			var perr *fs.PathError
+			assert.ErrorAs(t, err, &perr)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package writefs
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// accessMask selects the access mode bits of a Flag.
+const accessMask = ReadOnly | WriteOnly | ReadWrite
+
+// flagNames lists the names of Flag constants
+// that are not access modes, in String order.
+var flagNames = []struct {
+	flag Flag
+	name string
+}{
+	{Append, "Append"},
+	{Create, "Create"},
+	{Exclusive, "Exclusive"},
+	{Synchronous, "Synchronous"},
+	{Truncate, "Truncate"},
+}
+
+// String returns the names of the constants
+// that compose f, separated by a pipe, e.g.
+// "WriteOnly|Create|Truncate".
+// The access mode is always reported first.
+// Unknown bits are reported as an hexadecimal number.
+func (f Flag) String() string {
+	var parts []string
+	switch f.access() {
+	case ReadOnly:
+		parts = append(parts, "ReadOnly")
+	case WriteOnly:
+		parts = append(parts, "WriteOnly")
+	case ReadWrite:
+		parts = append(parts, "ReadWrite")
+	default:
+		parts = append(parts, "WriteOnly", "ReadWrite")
+	}
+
+	rest := f &^ accessMask
+	for _, n := range flagNames {
+		if rest&n.flag == n.flag {
+			parts = append(parts, n.name)
+			rest &^= n.flag
+		}
+	}
+	if rest != 0 {
+		parts = append(parts, fmt.Sprintf("0x%x", int(rest)))
+	}
+	return strings.Join(parts, "|")
+}
+
+// Validate checks that f does not contain
+// contradictory combinations of flags.
+// It returns an error wrapping fs.ErrInvalid when:
+//
+//   - both WriteOnly and ReadWrite are set;
+//   - Exclusive is set without Create;
+//   - Append is set without WriteOnly nor ReadWrite;
+//   - Truncate and Create are set without WriteOnly nor ReadWrite,
+//     since Truncate alone requests the deletion of the file.
+//
+// OpenFile function validates flags before forwarding the call,
+// so WriteFS implementations don't need to repeat these checks.
+func (f Flag) Validate() error {
+	switch {
+	case f.access() == accessMask:
+		return fmt.Errorf("%w flag: WriteOnly with ReadWrite", fs.ErrInvalid)
+	case f&Exclusive != 0 && f&Create == 0:
+		return fmt.Errorf("%w flag: Exclusive without Create", fs.ErrInvalid)
+	case f&Append != 0 && f.access() == ReadOnly:
+		return fmt.Errorf("%w flag: Append with ReadOnly", fs.ErrInvalid)
+	case f&Truncate != 0 && f&Create != 0 && f.access() == ReadOnly:
+		return fmt.Errorf("%w flag: Truncate and Create with ReadOnly", fs.ErrInvalid)
+	}
+	return nil
+}
+
+// access returns the access mode bits of f.
+func (f Flag) access() Flag {
+	return f & accessMask
+}
+
+// isMkdir reports whether flag and perm request
+// the creation of a directory, following
+// the conventions of WriteFS.OpenFile.
+func isMkdir(flag Flag, perm fs.FileMode) bool {
+	return flag&Create != 0 && perm&fs.ModeDir != 0
+}
+
+// isRemove reports whether flag requests
+// the deletion of a file or directory, following
+// the conventions of WriteFS.OpenFile.
+func isRemove(flag Flag) bool {
+	return flag&Truncate != 0 && flag.access() == ReadOnly
+}
@@ -0,0 +1,95 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	mockfs "github.com/parrogo/writefs/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyFS implements writefs.LegacyWriteFS
+type legacyFS struct {
+	*writefs.MemFS
+	flags []int
+}
+
+func (fsys *legacyFS) OpenFile(name string, flag int, perm fs.FileMode) (writefs.FileWriter, error) {
+	fsys.flags = append(fsys.flags, flag)
+	return fsys.MemFS.OpenFile(name, writefs.Flag(flag), perm)
+}
+
+func TestFlag(t *testing.T) {
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "WriteOnly|Create|Truncate", (writefs.WriteOnly | writefs.Create | writefs.Truncate).String())
+		assert.Equal(t, "ReadOnly", writefs.ReadOnly.String())
+		assert.Equal(t, "ReadWrite|Append|Synchronous", (writefs.ReadWrite | writefs.Append | writefs.Synchronous).String())
+		assert.Equal(t, "ReadOnly|Create|Exclusive", (writefs.Create | writefs.Exclusive).String())
+		assert.Equal(t, "WriteOnly|0x80000000", (writefs.WriteOnly | writefs.Flag(0x80000000)).String())
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		valid := []writefs.Flag{
+			writefs.ReadOnly,
+			writefs.WriteOnly | writefs.Create | writefs.Truncate,
+			writefs.ReadWrite | writefs.Append,
+			writefs.ReadWrite | writefs.Truncate,
+			writefs.ReadWrite | writefs.Create | writefs.Append | writefs.Truncate,
+			writefs.WriteOnly | writefs.Create | writefs.Exclusive,
+			writefs.Create | writefs.Exclusive,
+			writefs.Truncate,
+		}
+		for _, flag := range valid {
+			assert.NoError(t, flag.Validate(), flag.String())
+		}
+
+		invalid := map[writefs.Flag]string{
+			writefs.WriteOnly | writefs.ReadWrite:                    "invalid argument flag: WriteOnly with ReadWrite",
+			writefs.WriteOnly | writefs.Exclusive:                    "invalid argument flag: Exclusive without Create",
+			writefs.ReadOnly | writefs.Append:                        "invalid argument flag: Append with ReadOnly",
+			writefs.ReadOnly | writefs.Create | writefs.Truncate:     "invalid argument flag: Truncate and Create with ReadOnly",
+			writefs.ReadWrite | writefs.Exclusive | writefs.Truncate: "invalid argument flag: Exclusive without Create",
+		}
+		for flag, msg := range invalid {
+			err := flag.Validate()
+			assert.ErrorIs(t, err, fs.ErrInvalid, flag.String())
+			assert.EqualError(t, err, msg)
+		}
+	})
+
+	t.Run("OpenFile validates flag", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		f, err := writefs.OpenFile(&testfs, "dir1/file2", writefs.WriteOnly|writefs.Exclusive, 0644)
+		assert.Nil(t, f)
+		assert.EqualError(t, err, "OpenFile dir1/file2: invalid argument flag: Exclusive without Create")
+		testfs.AssertNotCalled(t, "OpenFile", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("OpenFile supports LegacyWriteFS", func(t *testing.T) {
+		fsys := &legacyFS{MemFS: writefs.NewMemFS(nil)}
+		f, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly|writefs.Create, 0644)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		assert.Equal(t, []int{os.O_WRONLY | os.O_CREATE}, fsys.flags)
+	})
+
+	t.Run("FromLegacy adapts LegacyWriteFS", func(t *testing.T) {
+		fsys := &legacyFS{MemFS: writefs.NewMemFS(nil)}
+		_, err := writefs.WriteFile(writefs.FromLegacy(fsys), "file1", []byte("ciao"))
+		require.NoError(t, err)
+		assert.Equal(t, []int{os.O_WRONLY | os.O_CREATE | os.O_TRUNC}, fsys.flags)
+	})
+
+	t.Run("OpenFileInt accepts os flags", func(t *testing.T) {
+		fsys := writefs.NewMemFS(nil)
+		f, err := writefs.OpenFileInt(fsys, "file1", os.O_WRONLY|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		_, err = fs.Stat(fsys, "file1")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,208 @@
+package writefs
+
+import (
+	"io"
+	"io/fs"
+)
+
+// Hooks contains the functions called by file systems
+// returned by WithHooks. Nil functions are skipped.
+//
+// Functions returning an error can veto the operation:
+// the operation is not executed, and the error is returned
+// to the caller wrapped in a *fs.PathError.
+type Hooks struct {
+	// BeforeOpen is called before OpenFile opens name,
+	// except when flag and perm request to delete a file
+	// or to create a directory.
+	BeforeOpen func(name string, flag Flag, perm fs.FileMode) error
+	// AfterOpen is called after OpenFile opened name,
+	// with the error returned by the underlying file system.
+	AfterOpen func(name string, flag Flag, perm fs.FileMode, err error)
+	// OnWrite is called before each Write, WriteAt and
+	// Truncate on files opened with WriteOnly or ReadWrite
+	// flags. p is nil for Truncate.
+	OnWrite func(name string, p []byte) error
+	// OnClose is called after closing a file opened with
+	// WriteOnly or ReadWrite flags, with the number
+	// of bytes written to it and the error returned by Close.
+	OnClose func(name string, written int64, err error)
+	// OnRemove is called before deleting
+	// name using the Truncate convention.
+	OnRemove func(name string) error
+	// OnMkdir is called before creating
+	// directory name using the Create convention.
+	OnMkdir func(name string, perm fs.FileMode) error
+	// OnRename is called before renaming oldname to newname.
+	OnRename func(oldname, newname string) error
+}
+
+// WithHooks returns a WriteFS that forwards every
+// operation to fsys, calling the functions of hooks
+// on write operations.
+//
+// Files opened with WriteOnly or ReadWrite flags are wrapped,
+// so that OnWrite and OnClose are called for them.
+// Since Remove, MkDir and MkdirAll functions fall back to OpenFile
+// conventions, OnRemove and OnMkdir are called for them too.
+// The returned file system implements RenameFS if fsys does,
+// calling OnRename.
+func WithHooks(fsys WriteFS, hooks Hooks) WriteFS {
+	return &hooksFS{fsys: fsys, hooks: hooks}
+}
+
+type hooksFS struct {
+	fsys  WriteFS
+	hooks Hooks
+}
+
+var (
+	_ WriteFS        = &hooksFS{}
+	_ RenameFS       = &hooksFS{}
+	_ CapabilitiesFS = &hooksFS{}
+	_ linkFS         = &hooksFS{}
+)
+
+// Capabilities implements CapabilitiesFS.
+// Capabilities of the underlying fsys are reported,
+// limited to the ones hooksFS forwards.
+func (h *hooksFS) Capabilities() Capability {
+	return Capabilities(h.fsys) & wrapperCaps
+}
+
+// Open implements fs.FS
+func (h *hooksFS) Open(name string) (fs.File, error) {
+	return h.fsys.Open(name)
+}
+
+// Lstat returns a fs.FileInfo describing the named file of the
+// underlying fsys, without following it if it is a symbolic link.
+func (h *hooksFS) Lstat(name string) (fs.FileInfo, error) {
+	return Lstat(h.fsys, name)
+}
+
+// Readlink returns the target of the named
+// symbolic link of the underlying fsys.
+func (h *hooksFS) Readlink(name string) (string, error) {
+	return Readlink(h.fsys, name)
+}
+
+// OpenFile implements WriteFS
+func (h *hooksFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	switch {
+	case isMkdir(flag, perm):
+		if h.hooks.OnMkdir != nil {
+			if err := h.hooks.OnMkdir(name, perm); err != nil {
+				return nil, opPathError("OpenFile", name, err)
+			}
+		}
+		return h.fsys.OpenFile(name, flag, perm)
+	case isRemove(flag):
+		if h.hooks.OnRemove != nil {
+			if err := h.hooks.OnRemove(name); err != nil {
+				return nil, opPathError("OpenFile", name, err)
+			}
+		}
+		return h.fsys.OpenFile(name, flag, perm)
+	}
+
+	if h.hooks.BeforeOpen != nil {
+		if err := h.hooks.BeforeOpen(name, flag, perm); err != nil {
+			return nil, opPathError("OpenFile", name, err)
+		}
+	}
+	file, err := h.fsys.OpenFile(name, flag, perm)
+	if h.hooks.AfterOpen != nil {
+		h.hooks.AfterOpen(name, flag, perm, err)
+	}
+	if err != nil || flag.access() == ReadOnly {
+		return file, err
+	}
+	return &hooksFile{FileWriter: file, hooks: &h.hooks, name: name}, nil
+}
+
+// Rename implements RenameFS.
+// It returns an error wrapping errUnsupported
+// if the underlying fsys does not implement RenameFS.
+func (h *hooksFS) Rename(oldname, newname string) error {
+	rfs, ok := h.fsys.(RenameFS)
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: errUnsupported}
+	}
+	if h.hooks.OnRename != nil {
+		if err := h.hooks.OnRename(oldname, newname); err != nil {
+			return opPathError("Rename", oldname, err)
+		}
+	}
+	return rfs.Rename(oldname, newname)
+}
+
+// hooksFile is a file opened for writing
+// through a hooksFS.
+type hooksFile struct {
+	FileWriter
+	hooks   *Hooks
+	name    string
+	written int64
+}
+
+// Write implements io.Writer
+func (f *hooksFile) Write(p []byte) (int, error) {
+	if f.hooks.OnWrite != nil {
+		if err := f.hooks.OnWrite(f.name, p); err != nil {
+			return 0, opPathError("Write", f.name, err)
+		}
+	}
+	n, err := f.FileWriter.Write(p)
+	f.written += int64(n)
+	return n, err
+}
+
+// Close implements fs.File
+func (f *hooksFile) Close() error {
+	err := f.FileWriter.Close()
+	if f.hooks.OnClose != nil {
+		f.hooks.OnClose(f.name, f.written, err)
+	}
+	return err
+}
+
+// Seek implements io.Seeker.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.Seeker.
+func (f *hooksFile) Seek(offset int64, whence int) (int64, error) {
+	return seekFile(f.FileWriter, f.name, offset, whence)
+}
+
+// WriteAt implements io.WriterAt, calling OnWrite.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.WriterAt.
+func (f *hooksFile) WriteAt(p []byte, off int64) (int, error) {
+	w, ok := f.FileWriter.(io.WriterAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: &UnsupportedError{Interface: "WriterAtFile"}}
+	}
+	if f.hooks.OnWrite != nil {
+		if err := f.hooks.OnWrite(f.name, p); err != nil {
+			return 0, opPathError("WriteAt", f.name, err)
+		}
+	}
+	n, err := w.WriteAt(p, off)
+	f.written += int64(n)
+	return n, err
+}
+
+// Truncate implements TruncaterFile, calling OnWrite with a nil p.
+func (f *hooksFile) Truncate(size int64) error {
+	if _, ok := f.FileWriter.(TruncaterFile); ok && f.hooks.OnWrite != nil {
+		if err := f.hooks.OnWrite(f.name, nil); err != nil {
+			return opPathError("Truncate", f.name, err)
+		}
+	}
+	return truncateFile(f.FileWriter, f.name, size)
+}
+
+// Sync implements SyncerFile
+func (f *hooksFile) Sync() error {
+	return syncFile(f.FileWriter, f.name)
+}
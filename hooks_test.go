@@ -0,0 +1,146 @@
+package writefs_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLogHooks returns Hooks that
+// append every call to log.
+func newLogHooks(log *[]string) writefs.Hooks {
+	return writefs.Hooks{
+		BeforeOpen: func(name string, flag writefs.Flag, perm fs.FileMode) error {
+			*log = append(*log, fmt.Sprintf("BeforeOpen %s %s %s", name, flag, perm))
+			return nil
+		},
+		AfterOpen: func(name string, flag writefs.Flag, perm fs.FileMode, err error) {
+			*log = append(*log, fmt.Sprintf("AfterOpen %s %v", name, err))
+		},
+		OnWrite: func(name string, p []byte) error {
+			*log = append(*log, fmt.Sprintf("OnWrite %s %q", name, p))
+			return nil
+		},
+		OnClose: func(name string, written int64, err error) {
+			*log = append(*log, fmt.Sprintf("OnClose %s %d %v", name, written, err))
+		},
+		OnRemove: func(name string) error {
+			*log = append(*log, "OnRemove "+name)
+			return nil
+		},
+		OnMkdir: func(name string, perm fs.FileMode) error {
+			*log = append(*log, fmt.Sprintf("OnMkdir %s %s", name, perm))
+			return nil
+		},
+		OnRename: func(oldname, newname string) error {
+			*log = append(*log, "OnRename "+oldname+" "+newname)
+			return nil
+		},
+	}
+}
+
+func TestWithHooks(t *testing.T) {
+	t.Run("calls hooks", func(t *testing.T) {
+		var log []string
+		fsys := writefs.WithHooks(newTreeFS(), newLogHooks(&log))
+
+		_, err := writefs.WriteFile(fsys, "file1", []byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, writefs.MkdirAll(fsys, "dir5/dir6", 0755))
+		require.NoError(t, writefs.Rename(fsys, "file1", "dir5/file1"))
+		require.NoError(t, writefs.RemoveAll(fsys, "dir1"))
+		_, err = fs.ReadFile(fsys, "dir5/file1")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"BeforeOpen file1 WriteOnly|Create|Truncate -rw-r--r--",
+			"AfterOpen file1 <nil>",
+			`OnWrite file1 "hello"`,
+			"OnClose file1 5 <nil>",
+			"OnMkdir dir5/dir6 drwxr-xr-x",
+			"OnRename file1 dir5/file1",
+			"OnRemove dir1",
+		}, log)
+	})
+
+	t.Run("calls OnWrite on WriteAt and Truncate", func(t *testing.T) {
+		var log []string
+		fsys := writefs.WithHooks(newTreeFS(), writefs.Hooks{OnWrite: newLogHooks(&log).OnWrite})
+		file, err := writefs.OpenFile(fsys, "file1", writefs.ReadWrite, 0)
+		require.NoError(t, err)
+		_, err = writefs.WriteAt(file, []byte("AO"), 2)
+		require.NoError(t, err)
+		require.NoError(t, writefs.TruncateFile(file, 3))
+		require.NoError(t, file.Close())
+		assert.Equal(t, []string{`OnWrite file1 "AO"`, `OnWrite file1 ""`}, log)
+	})
+
+	t.Run("does not wrap ReadOnly files", func(t *testing.T) {
+		var log []string
+		fsys := writefs.WithHooks(newTreeFS(), newLogHooks(&log))
+		dir, err := writefs.OpenFile(fsys, "dir1", writefs.ReadOnly, 0)
+		require.NoError(t, err)
+		_, ok := dir.(fs.ReadDirFile)
+		assert.True(t, ok)
+		require.NoError(t, dir.Close())
+		assert.Equal(t, []string{"BeforeOpen dir1 ReadOnly ----------", "AfterOpen dir1 <nil>"}, log)
+	})
+
+	vetoed := errors.New("vetoed")
+	for name, test := range map[string]struct {
+		hooks writefs.Hooks
+		op    func(fsys writefs.WriteFS) error
+	}{
+		"BeforeOpen": {
+			writefs.Hooks{BeforeOpen: func(string, writefs.Flag, fs.FileMode) error { return vetoed }},
+			func(fsys writefs.WriteFS) error {
+				_, err := writefs.WriteFile(fsys, "file1", []byte("hello"))
+				return err
+			},
+		},
+		"OnWrite": {
+			writefs.Hooks{OnWrite: func(string, []byte) error { return vetoed }},
+			func(fsys writefs.WriteFS) error {
+				_, err := writefs.AppendFile(fsys, "file1", []byte("hello"))
+				return err
+			},
+		},
+		"OnWrite on Truncate": {
+			writefs.Hooks{OnWrite: func(string, []byte) error { return vetoed }},
+			func(fsys writefs.WriteFS) error {
+				file, err := writefs.OpenFile(fsys, "file1", writefs.ReadWrite, 0)
+				require.NoError(t, err)
+				defer file.Close()
+				return writefs.TruncateFile(file, 0)
+			},
+		},
+		"OnRemove": {
+			writefs.Hooks{OnRemove: func(string) error { return vetoed }},
+			func(fsys writefs.WriteFS) error { return writefs.Remove(fsys, "file1") },
+		},
+		"OnMkdir": {
+			writefs.Hooks{OnMkdir: func(string, fs.FileMode) error { return vetoed }},
+			func(fsys writefs.WriteFS) error { return writefs.MkDir(fsys, "dir5", 0755) },
+		},
+		"OnRename": {
+			writefs.Hooks{OnRename: func(string, string) error { return vetoed }},
+			func(fsys writefs.WriteFS) error { return writefs.Rename(fsys, "file1", "file5") },
+		},
+	} {
+		t.Run(name+" vetoes operations", func(t *testing.T) {
+			mem := newTreeFS()
+			before := mem.MapFS()
+
+			err := test.op(writefs.WithHooks(mem, test.hooks))
+			assert.ErrorIs(t, err, vetoed)
+			var perr *fs.PathError
+			assert.ErrorAs(t, err, &perr)
+			assert.Equal(t, before, mem.MapFS())
+		})
+	}
+}
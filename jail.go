@@ -0,0 +1,375 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// Jail returns a WriteFS corresponding to the subtree rooted
+// at fsys's dir, that refuses to access anything outside of it.
+//
+// Every name is resolved one component at a time before being
+// forwarded to fsys. If fsys implements Lstat and Readlink methods,
+// as the file systems returned by DirFS and the wrappers of this
+// package do, symbolic links found along the name are followed,
+// and their targets checked to stay inside dir. Targets that are
+// absolute paths are always refused. A fsys without those methods
+// is assumed to contain no symbolic links: wrappers from other
+// packages must forward them for links of the file system they
+// wrap to be checked.
+// Symbolic links escaping dir, and names whose ".." elements
+// climb above dir, are refused with a *fs.PathError wrapping
+// fs.ErrPermission, while other names that are not valid
+// according to fs.ValidPath are refused with fs.ErrInvalid.
+//
+// Deleting a symbolic link using the Truncate convention,
+// Remove and Rename act on the link, never on its target.
+//
+// Since names are resolved before calling fsys, Jail cannot
+// protect against symbolic links concurrently changed by
+// other processes between resolution and the actual operation.
+func Jail(fsys WriteFS, dir string) (WriteFS, error) {
+	if !fs.ValidPath(dir) {
+		err := fmt.Errorf("%w dir: not a valid path", fs.ErrInvalid)
+		return nil, &fs.PathError{Op: "Jail", Path: dir, Err: err}
+	}
+	return &jailFS{fsys: fsys, dir: dir}, nil
+}
+
+type jailFS struct {
+	fsys WriteFS
+	dir  string
+}
+
+var (
+	_ WriteFS        = &jailFS{}
+	_ fs.StatFS      = &jailFS{}
+	_ fs.ReadDirFS   = &jailFS{}
+	_ fs.SubFS       = &jailFS{}
+	_ RemoveFS       = &jailFS{}
+	_ MkDirFS        = &jailFS{}
+	_ RenameFS       = &jailFS{}
+	_ linkFS         = &jailFS{}
+	_ CapabilitiesFS = &jailFS{}
+	_ ChmodFS        = &jailFS{}
+	_ ChownFS        = &jailFS{}
+	_ ChtimesFS      = &jailFS{}
+	_ SymlinkFS      = &jailFS{}
+	_ LinkFS         = &jailFS{}
+)
+
+// resolve converts name to the name used in the underlying fsys.
+// Any symbolic link found along name is followed and checked
+// to not escape j.dir. When followLast is false, the last element
+// of name is not followed even if it is a symbolic link.
+func (j *jailFS) resolve(op string, name string, followLast bool) (string, error) {
+	return resolveLinks(j.fsys, j.dir, op, name, followLast)
+}
+
+// resolveLinks converts name, relative to dir, to a name of fsys.
+// Any symbolic link found along name is followed, if fsys can
+// report them, and checked to not escape dir. When followLast
+// is false, the last element of name is not followed even if
+// it is a symbolic link.
+func resolveLinks(fsys fs.FS, dir string, op string, name string, followLast bool) (string, error) {
+	if !fs.ValidPath(name) {
+		if climbs(name) {
+			return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+		}
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	lfs, canLink := fsys.(linkFS)
+	var parts []string
+	if name != "." {
+		parts = strings.Split(name, "/")
+	}
+
+	var resolved []string
+	links := 0
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, part)
+		if !canLink || len(parts) == 0 && !followLast {
+			continue
+		}
+
+		full := path.Join(dir, path.Join(resolved...))
+		info, err := lfs.Lstat(full)
+		if errors.Is(err, errUnsupported) {
+			canLink = false
+			continue
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", jailError(name, err)
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+		}
+		target, err := lfs.Readlink(full)
+		if err != nil {
+			return "", jailError(name, err)
+		}
+		if path.IsAbs(target) {
+			return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+		}
+		resolved = resolved[:len(resolved)-1]
+		parts = append(strings.Split(target, "/"), parts...)
+	}
+
+	return path.Join(dir, path.Join(resolved...)), nil
+}
+
+// climbs reports whether the ".." elements of name climb
+// above the root before any other invalid element is found.
+func climbs(name string) bool {
+	depth := 0
+	for _, part := range strings.Split(name, "/") {
+		switch part {
+		case "", ".":
+			return false
+		case "..":
+			depth--
+			if depth < 0 {
+				return true
+			}
+		default:
+			depth++
+		}
+	}
+	return false
+}
+
+// jailError rewrites the path of *fs.PathError
+// to the name used by the caller.
+func jailError(name string, err error) error {
+	var perr *fs.PathError
+	if errors.As(err, &perr) {
+		return &fs.PathError{Op: perr.Op, Path: name, Err: perr.Err}
+	}
+	return err
+}
+
+// Open implements fs.FS
+func (j *jailFS) Open(name string) (fs.File, error) {
+	full, err := j.resolve("Open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	file, err := j.fsys.Open(full)
+	return file, jailError(name, err)
+}
+
+// OpenFile implements WriteFS
+func (j *jailFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	full, err := j.resolve("OpenFile", name, !isRemove(flag))
+	if err != nil {
+		return nil, err
+	}
+	if isRemove(flag) && name == "." {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+	file, err := j.fsys.OpenFile(full, flag, perm)
+	return file, jailError(name, err)
+}
+
+// Stat implements fs.StatFS
+func (j *jailFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := j.resolve("Stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(j.fsys, full)
+	return info, jailError(name, err)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (j *jailFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := j.resolve("ReadDir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(j.fsys, full)
+	return entries, jailError(name, err)
+}
+
+// Sub implements fs.SubFS
+func (j *jailFS) Sub(dir string) (fs.FS, error) {
+	full, err := j.resolve("Sub", dir, true)
+	if err != nil {
+		return nil, err
+	}
+	return &jailFS{fsys: j.fsys, dir: full}, nil
+}
+
+// Lstat returns a fs.FileInfo describing the named file,
+// without following it if it is a symbolic link.
+// It returns an error wrapping errUnsupported if
+// the underlying fsys cannot report symbolic links.
+func (j *jailFS) Lstat(name string) (fs.FileInfo, error) {
+	lfs, ok := j.fsys.(linkFS)
+	if !ok {
+		return nil, &fs.PathError{Op: "Lstat", Path: name, Err: errUnsupported}
+	}
+	full, err := j.resolve("Lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	info, err := lfs.Lstat(full)
+	return info, jailError(name, err)
+}
+
+// Readlink returns the target of the named symbolic link.
+// It returns an error wrapping errUnsupported if
+// the underlying fsys cannot report symbolic links.
+func (j *jailFS) Readlink(name string) (string, error) {
+	lfs, ok := j.fsys.(linkFS)
+	if !ok {
+		return "", &fs.PathError{Op: "Readlink", Path: name, Err: errUnsupported}
+	}
+	full, err := j.resolve("Readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	target, err := lfs.Readlink(full)
+	return target, jailError(name, err)
+}
+
+// Capabilities implements CapabilitiesFS
+func (j *jailFS) Capabilities() Capability {
+	return Capabilities(j.fsys)
+}
+
+// Remove implements RemoveFS
+func (j *jailFS) Remove(name string) error {
+	full, err := j.resolve("Remove", name, false)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return &fs.PathError{Op: "Remove", Path: name, Err: fs.ErrInvalid}
+	}
+	return jailError(name, Remove(j.fsys, full))
+}
+
+// MkDir implements MkDirFS
+func (j *jailFS) MkDir(name string, perm fs.FileMode) error {
+	full, err := j.resolve("MkDir", name, true)
+	if err != nil {
+		return err
+	}
+	return jailError(name, MkDir(j.fsys, full, perm))
+}
+
+// Rename implements RenameFS.
+// It returns an error wrapping errUnsupported
+// if the underlying fsys does not implement RenameFS.
+func (j *jailFS) Rename(oldname, newname string) error {
+	oldFull, err := j.resolve("Rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	newFull, err := j.resolve("Rename", newname, false)
+	if err != nil {
+		return err
+	}
+	rfs, ok := j.fsys.(RenameFS)
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: errUnsupported}
+	}
+	return jailError(oldname, rfs.Rename(oldFull, newFull))
+}
+
+// Chmod implements ChmodFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement ChmodFS.
+func (j *jailFS) Chmod(name string, mode fs.FileMode) error {
+	full, err := j.resolve("Chmod", name, true)
+	if err != nil {
+		return err
+	}
+	return jailError(name, Chmod(j.fsys, full, mode))
+}
+
+// Chown implements ChownFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement ChownFS.
+func (j *jailFS) Chown(name string, uid, gid int) error {
+	full, err := j.resolve("Chown", name, true)
+	if err != nil {
+		return err
+	}
+	return jailError(name, Chown(j.fsys, full, uid, gid))
+}
+
+// Chtimes implements ChtimesFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement ChtimesFS.
+func (j *jailFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	full, err := j.resolve("Chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	return jailError(name, Chtimes(j.fsys, full, atime, mtime))
+}
+
+// Symlink implements SymlinkFS.
+// Targets pointing outside of dir are refused
+// with a *fs.PathError wrapping fs.ErrPermission.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement SymlinkFS.
+func (j *jailFS) Symlink(oldname, newname string) error {
+	full, err := j.resolve("Symlink", newname, false)
+	if err != nil {
+		return err
+	}
+	if newname == "." || oldname == "" || path.IsAbs(oldname) {
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: fs.ErrInvalid}
+	}
+	target := path.Join(path.Dir(full), oldname)
+	inside := j.dir == "." || target == j.dir || strings.HasPrefix(target, j.dir+"/")
+	if !inside || !fs.ValidPath(target) {
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: fs.ErrPermission}
+	}
+	return jailError(newname, Symlink(j.fsys, oldname, full))
+}
+
+// Link implements LinkFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement LinkFS.
+func (j *jailFS) Link(oldname, newname string) error {
+	oldFull, err := j.resolve("Link", oldname, false)
+	if err != nil {
+		return err
+	}
+	newFull, err := j.resolve("Link", newname, false)
+	if err != nil {
+		return err
+	}
+	return jailError(oldname, Link(j.fsys, oldFull, newFull))
+}
@@ -0,0 +1,185 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJailDir returns a host directory containing
+// a jail directory with symbolic links inside and
+// outside of it.
+func newJailDir(t *testing.T) string {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "jail", "dir1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "jail", "dir1", "file2"), []byte("ciao"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0644))
+	if err := os.Symlink("../secret", filepath.Join(root, "jail", "escape")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	require.NoError(t, os.Symlink("../../jail/..", filepath.Join(root, "jail", "dir1", "up")))
+	require.NoError(t, os.Symlink("dir1/file2", filepath.Join(root, "jail", "inside")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "secret"), filepath.Join(root, "jail", "absolute")))
+	require.NoError(t, os.Symlink("loop", filepath.Join(root, "jail", "loop")))
+	return root
+}
+
+func TestJail(t *testing.T) {
+	t.Run("follows links inside dir", func(t *testing.T) {
+		root := newJailDir(t)
+		fsys, err := writefs.Jail(writefs.DirFS(root), "jail")
+		require.NoError(t, err)
+
+		data, err := fs.ReadFile(fsys, "inside")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+
+		_, err = writefs.WriteFile(fsys, "inside", []byte("hello"))
+		require.NoError(t, err)
+		data, err = os.ReadFile(filepath.Join(root, "jail", "dir1", "file2"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	for _, name := range []string{"escape", "dir1/up/secret", "absolute"} {
+		t.Run("refuses "+name, func(t *testing.T) {
+			root := newJailDir(t)
+			fsys, err := writefs.Jail(writefs.DirFS(root), "jail")
+			require.NoError(t, err)
+
+			_, err = fsys.Open(name)
+			assert.ErrorIs(t, err, fs.ErrPermission)
+			_, err = writefs.WriteFile(fsys, name, []byte("hello"))
+			assert.ErrorIs(t, err, fs.ErrPermission)
+			var perr *fs.PathError
+			require.ErrorAs(t, err, &perr)
+			assert.Equal(t, name, perr.Path)
+
+			data, err := os.ReadFile(filepath.Join(root, "secret"))
+			require.NoError(t, err)
+			assert.Equal(t, "secret", string(data))
+		})
+	}
+
+	for name, wrap := range map[string]func(fsys writefs.WriteFS) writefs.WriteFS{
+		"ReadOnlyFS": writefs.ReadOnlyFS,
+		"WithQuota": func(fsys writefs.WriteFS) writefs.WriteFS {
+			return writefs.WithQuota(fsys, -1, -1)
+		},
+		"WithHooks": func(fsys writefs.WriteFS) writefs.WriteFS {
+			return writefs.WithHooks(fsys, writefs.Hooks{})
+		},
+		"WithWatch": func(fsys writefs.WriteFS) writefs.WriteFS {
+			return writefs.WithWatch(fsys)
+		},
+		"Overlay upper": func(fsys writefs.WriteFS) writefs.WriteFS {
+			return writefs.Overlay(fstest.MapFS{}, fsys)
+		},
+		"Overlay base": func(fsys writefs.WriteFS) writefs.WriteFS {
+			return writefs.Overlay(fsys, writefs.NewMemFS(nil))
+		},
+		"Mount": func(fsys writefs.WriteFS) writefs.WriteFS {
+			m := &writefs.Mount{}
+			require.NoError(t, m.Mount(".", fsys))
+			return m
+		},
+	} {
+		t.Run("checks links through "+name, func(t *testing.T) {
+			root := newJailDir(t)
+			fsys, err := writefs.Jail(wrap(writefs.DirFS(root)), "jail")
+			require.NoError(t, err)
+
+			data, err := fs.ReadFile(fsys, "inside")
+			require.NoError(t, err)
+			assert.Equal(t, "ciao", string(data))
+			for _, name := range []string{"escape", "dir1/up/secret", "absolute"} {
+				_, err = fs.ReadFile(fsys, name)
+				assert.ErrorIs(t, err, fs.ErrPermission, name)
+			}
+		})
+	}
+
+	t.Run("deletes links, not their target", func(t *testing.T) {
+		root := newJailDir(t)
+		fsys, err := writefs.Jail(writefs.DirFS(root), "jail")
+		require.NoError(t, err)
+
+		_, err = writefs.OpenFile(fsys, "escape", writefs.Truncate, 0)
+		require.NoError(t, err)
+		require.NoError(t, writefs.Remove(fsys, "inside"))
+		_, err = os.Lstat(filepath.Join(root, "jail", "escape"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = os.Stat(filepath.Join(root, "secret"))
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(root, "jail", "dir1", "file2"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns ErrInvalid for link loops", func(t *testing.T) {
+		fsys, err := writefs.Jail(writefs.DirFS(newJailDir(t)), "jail")
+		require.NoError(t, err)
+		_, err = fsys.Open("loop")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+
+	t.Run("reports links", func(t *testing.T) {
+		fsys, err := writefs.Jail(writefs.DirFS(newJailDir(t)), "jail")
+		require.NoError(t, err)
+		lfs := fsys.(interface {
+			Lstat(name string) (fs.FileInfo, error)
+			Readlink(name string) (string, error)
+		})
+		info, err := lfs.Lstat("inside")
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&fs.ModeSymlink)
+		target, err := lfs.Readlink("inside")
+		require.NoError(t, err)
+		assert.Equal(t, "dir1/file2", target)
+	})
+
+	t.Run("jails subtrees", func(t *testing.T) {
+		fsys, err := writefs.Jail(writefs.DirFS(newJailDir(t)), ".")
+		require.NoError(t, err)
+		sub, err := fs.Sub(fsys, "jail")
+		require.NoError(t, err)
+		_, err = sub.Open("escape")
+		assert.ErrorIs(t, err, fs.ErrPermission)
+		_, ok := sub.(writefs.WriteFS)
+		assert.True(t, ok)
+	})
+
+	t.Run("works with file systems without links", func(t *testing.T) {
+		mem := newTreeFS()
+		fsys, err := writefs.Jail(mem, "dir1")
+		require.NoError(t, err)
+		require.NoError(t, writefs.MkdirAll(fsys, "dir5", 0755))
+		require.NoError(t, writefs.Rename(fsys, "file2", "dir5/file2"))
+		data, err := fs.ReadFile(mem, "dir1/dir5/file2")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+
+		for _, name := range []string{"../file1", "dir5/../../file1", ".."} {
+			_, err = fsys.Open(name)
+			assert.ErrorIs(t, err, fs.ErrPermission, name)
+			_, err = fsys.OpenFile(name, writefs.WriteOnly|writefs.Create, 0644)
+			assert.ErrorIs(t, err, fs.ErrPermission, name)
+			_, err = fsys.(fs.StatFS).Stat(name)
+			assert.ErrorIs(t, err, fs.ErrPermission, name)
+		}
+		for _, name := range []string{"/file1", "dir5/../file2", "file2/"} {
+			_, err = fsys.Open(name)
+			assert.ErrorIs(t, err, fs.ErrInvalid, name)
+		}
+	})
+
+	t.Run("return PathError for invalid dir", func(t *testing.T) {
+		_, err := writefs.Jail(newTreeFS(), "/")
+		assert.Equal(t, "Jail /: invalid argument dir: not a valid path", err.Error())
+	})
+}
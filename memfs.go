@@ -0,0 +1,726 @@
+package writefs
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// MemFS is an in-memory WriteFS, safe for concurrent use.
+// It extends the semantics of fstest.MapFS to support writes:
+// a MemFS could be seeded from a fstest.MapFS using NewMemFS, and
+// a snapshot of its content could be taken at any time using
+// its MapFS method.
+//
+// OpenFile honors every Flag constant (Synchronous is a no-op),
+// and implements the OpenFile conventions documented on WriteFS to
+// create directories and to delete files or directories recursively.
+// It also implements RemoveFS, MkDirFS and RenameFS.
+// Files returned by OpenFile and Open implements io.Seeker and io.ReaderAt.
+//
+// The zero value is an empty file system ready to use.
+type MemFS struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+var (
+	_ WriteFS        = &MemFS{}
+	_ fs.StatFS      = &MemFS{}
+	_ fs.ReadDirFS   = &MemFS{}
+	_ fs.ReadFileFS  = &MemFS{}
+	_ RemoveFS       = &MemFS{}
+	_ MkDirFS        = &MemFS{}
+	_ RenameFS       = &MemFS{}
+	_ CapabilitiesFS = &MemFS{}
+	_ ChmodFS        = &MemFS{}
+	_ ChtimesFS      = &MemFS{}
+)
+
+// memNode contains data and metadata of a single
+// file or directory of a MemFS.
+type memNode struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+	sys     interface{}
+}
+
+// NewMemFS returns a new MemFS containing a copy of
+// all files in seed. Parent directories missing in seed are
+// created with mode 0755. seed could be nil.
+func NewMemFS(seed fstest.MapFS) *MemFS {
+	fsys := &MemFS{}
+	fsys.init()
+	for name, file := range seed {
+		if !fs.ValidPath(name) || name == "." || file == nil {
+			continue
+		}
+		data := make([]byte, len(file.Data))
+		copy(data, file.Data)
+		if node, ok := fsys.nodes[name]; ok {
+			node.data, node.mode, node.modTime, node.sys = data, file.Mode, file.ModTime, file.Sys
+		} else {
+			fsys.nodes[name] = &memNode{data: data, mode: file.Mode, modTime: file.ModTime, sys: file.Sys}
+		}
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if _, ok := fsys.nodes[dir]; ok {
+				break
+			}
+			fsys.nodes[dir] = &memNode{mode: fs.ModeDir | 0755}
+		}
+	}
+	return fsys
+}
+
+// MapFS returns a snapshot of the content
+// of the file system as a fstest.MapFS.
+// Directories are included as explicit entries.
+func (fsys *MemFS) MapFS() fstest.MapFS {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+
+	res := fstest.MapFS{}
+	for name, node := range fsys.nodes {
+		if name == "." {
+			continue
+		}
+		data := make([]byte, len(node.data))
+		copy(data, node.data)
+		res[name] = &fstest.MapFile{Data: data, Mode: node.mode, ModTime: node.modTime, Sys: node.sys}
+	}
+	return res
+}
+
+// init initializes nodes map with the root directory.
+// The caller must hold the write lock or
+// be the only owner of fsys.
+func (fsys *MemFS) init() {
+	if fsys.nodes == nil {
+		fsys.nodes = map[string]*memNode{
+			".": {mode: fs.ModeDir | 0755},
+		}
+	}
+}
+
+// lookup returns the node for name,
+// or a *fs.PathError if it does not exist.
+// The caller must hold the lock.
+func (fsys *MemFS) lookup(op string, name string) (*memNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." && fsys.nodes == nil {
+		return &memNode{mode: fs.ModeDir | 0755}, nil
+	}
+	node, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return node, nil
+}
+
+// entries returns the sorted list of
+// children of directory name.
+// The caller must hold the lock.
+func (fsys *MemFS) entries(name string) []fs.DirEntry {
+	var res []fs.DirEntry
+	for childName, child := range fsys.nodes {
+		if childName != "." && path.Dir(childName) == name {
+			res = append(res, memDirEntry{child.info(path.Base(childName))})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name() < res[j].Name()
+	})
+	return res
+}
+
+// Open implements fs.FS
+func (fsys *MemFS) Open(name string) (fs.File, error) {
+	return fsys.OpenFile(name, ReadOnly, 0)
+}
+
+// Stat implements fs.StatFS
+func (fsys *MemFS) Stat(name string) (fs.FileInfo, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+
+	node, err := fsys.lookup("Stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(path.Base(name)), nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (fsys *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+
+	node, err := fsys.lookup("ReadDir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "ReadDir", Path: name, Err: ErrNotDir}
+	}
+	return fsys.entries(name), nil
+}
+
+// ReadFile implements fs.ReadFileFS
+func (fsys *MemFS) ReadFile(name string) ([]byte, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+
+	node, err := fsys.lookup("ReadFile", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "ReadFile", Path: name, Err: ErrIsDir}
+	}
+	data := make([]byte, len(node.data))
+	copy(data, node.data)
+	return data, nil
+}
+
+// OpenFile implements WriteFS
+func (fsys *MemFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	fsys.init()
+
+	if isMkdir(flag, perm) {
+		return nil, fsys.mkdir(name, flag, perm)
+	}
+	if isRemove(flag) {
+		return nil, fsys.remove(name)
+	}
+
+	node, exists := fsys.nodes[name]
+	if exists && flag&Create != 0 && flag&Exclusive != 0 {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrExist}
+	}
+	if !exists {
+		if flag&Create == 0 {
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrNotExist}
+		}
+		parent, ok := fsys.nodes[path.Dir(name)]
+		if !ok {
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrNotExist}
+		}
+		if !parent.mode.IsDir() {
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: ErrNotDir}
+		}
+		node = &memNode{mode: perm & fs.ModePerm, modTime: time.Now()}
+		fsys.nodes[name] = node
+	}
+
+	if node.mode.IsDir() {
+		if flag.access() != ReadOnly {
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: ErrIsDir}
+		}
+		return &memDir{
+			name:    name,
+			info:    node.info(path.Base(name)),
+			entries: fsys.entries(name),
+		}, nil
+	}
+
+	if flag&Truncate != 0 {
+		node.data = nil
+		node.modTime = time.Now()
+	}
+	return &memFile{fsys: fsys, name: name, node: node, flag: flag}, nil
+}
+
+// Remove implements RemoveFS
+func (fsys *MemFS) Remove(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "Remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	node, ok := fsys.nodes[name]
+	if !ok {
+		return &fs.PathError{Op: "Remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.mode.IsDir() && len(fsys.entries(name)) > 0 {
+		return &fs.PathError{Op: "Remove", Path: name, Err: ErrNotEmpty}
+	}
+	delete(fsys.nodes, name)
+	return nil
+}
+
+// MkDir implements MkDirFS
+func (fsys *MemFS) MkDir(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "MkDir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	fsys.init()
+
+	if _, ok := fsys.nodes[name]; ok {
+		return &fs.PathError{Op: "MkDir", Path: name, Err: fs.ErrExist}
+	}
+	parent, ok := fsys.nodes[path.Dir(name)]
+	if !ok {
+		return &fs.PathError{Op: "MkDir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !parent.mode.IsDir() {
+		return &fs.PathError{Op: "MkDir", Path: name, Err: ErrNotDir}
+	}
+	fsys.nodes[name] = &memNode{mode: fs.ModeDir | perm&fs.ModePerm, modTime: time.Now()}
+	return nil
+}
+
+// Rename implements RenameFS
+func (fsys *MemFS) Rename(oldname, newname string) error {
+	for _, name := range []string{oldname, newname} {
+		if !fs.ValidPath(name) || name == "." {
+			return &fs.PathError{Op: "Rename", Path: name, Err: fs.ErrInvalid}
+		}
+	}
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	oldNode, ok := fsys.nodes[oldname]
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: fs.ErrNotExist}
+	}
+	if oldname == newname {
+		return nil
+	}
+	if strings.HasPrefix(newname, oldname+"/") {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: fs.ErrInvalid}
+	}
+	parent, ok := fsys.nodes[path.Dir(newname)]
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: newname, Err: fs.ErrNotExist}
+	}
+	if !parent.mode.IsDir() {
+		return &fs.PathError{Op: "Rename", Path: newname, Err: ErrNotDir}
+	}
+
+	if newNode, ok := fsys.nodes[newname]; ok {
+		switch {
+		case newNode.mode.IsDir() && !oldNode.mode.IsDir():
+			return &fs.PathError{Op: "Rename", Path: newname, Err: fs.ErrExist}
+		case newNode.mode.IsDir() && len(fsys.entries(newname)) > 0:
+			return &fs.PathError{Op: "Rename", Path: newname, Err: ErrNotEmpty}
+		case !newNode.mode.IsDir() && oldNode.mode.IsDir():
+			return &fs.PathError{Op: "Rename", Path: newname, Err: ErrNotDir}
+		}
+	}
+
+	moved := map[string]*memNode{}
+	prefix := oldname + "/"
+	for name, node := range fsys.nodes {
+		if name == oldname || strings.HasPrefix(name, prefix) {
+			moved[newname+name[len(oldname):]] = node
+			delete(fsys.nodes, name)
+		}
+	}
+	for name, node := range moved {
+		fsys.nodes[name] = node
+	}
+	return nil
+}
+
+// Capabilities implements CapabilitiesFS
+func (fsys *MemFS) Capabilities() Capability {
+	return CapWritable | CapRemove | CapMkdir | CapRename | CapChmod | CapChtimes | CapSync | CapTruncate | CapSeek
+}
+
+// Chmod implements ChmodFS.
+// Only permission bits and the ModeSetuid,
+// ModeSetgid and ModeSticky bits are changed.
+func (fsys *MemFS) Chmod(name string, mode fs.FileMode) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	fsys.init()
+
+	node, err := fsys.lookup("Chmod", name)
+	if err != nil {
+		return err
+	}
+	const chmodMask = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+	node.mode = node.mode&^chmodMask | mode&chmodMask
+	return nil
+}
+
+// Chtimes implements ChtimesFS.
+// MemFS does not record access times, so atime is ignored.
+func (fsys *MemFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	fsys.init()
+
+	node, err := fsys.lookup("Chtimes", name)
+	if err != nil {
+		return err
+	}
+	node.modTime = mtime
+	return nil
+}
+
+// mkdir creates directory name and any missing parent.
+// When flag contains Exclusive, name itself must not exist.
+// The caller must hold the write lock.
+func (fsys *MemFS) mkdir(name string, flag Flag, perm fs.FileMode) error {
+	if node, ok := fsys.nodes[name]; ok {
+		if flag&Exclusive != 0 || !node.mode.IsDir() {
+			return &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrExist}
+		}
+		return nil
+	}
+
+	var missing []string
+	for dir := name; ; dir = path.Dir(dir) {
+		node, ok := fsys.nodes[dir]
+		if ok {
+			if !node.mode.IsDir() {
+				return &fs.PathError{Op: "OpenFile", Path: name, Err: ErrNotDir}
+			}
+			break
+		}
+		missing = append(missing, dir)
+	}
+
+	now := time.Now()
+	for _, dir := range missing {
+		fsys.nodes[dir] = &memNode{mode: fs.ModeDir | perm&fs.ModePerm, modTime: now}
+	}
+	return nil
+}
+
+// remove deletes name and, if it is a directory, all its content.
+// The caller must hold the write lock.
+func (fsys *MemFS) remove(name string) error {
+	if name == "." {
+		return &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := fsys.nodes[name]; !ok {
+		return &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrNotExist}
+	}
+	prefix := name + "/"
+	for childName := range fsys.nodes {
+		if childName == name || strings.HasPrefix(childName, prefix) {
+			delete(fsys.nodes, childName)
+		}
+	}
+	return nil
+}
+
+// info returns a fs.FileInfo for node.
+func (node *memNode) info(name string) fs.FileInfo {
+	return &memFileInfo{
+		name:    name,
+		size:    int64(len(node.data)),
+		mode:    node.mode,
+		modTime: node.modTime,
+		sys:     node.sys,
+	}
+}
+
+// memFileInfo implements fs.FileInfo
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	sys     interface{}
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return i.size }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *memFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memFileInfo) Sys() interface{}   { return i.sys }
+
+// memDirEntry implements fs.DirEntry
+type memDirEntry struct {
+	info fs.FileInfo
+}
+
+func (e memDirEntry) Name() string               { return e.info.Name() }
+func (e memDirEntry) IsDir() bool                { return e.info.IsDir() }
+func (e memDirEntry) Type() fs.FileMode          { return e.info.Mode().Type() }
+func (e memDirEntry) Info() (fs.FileInfo, error) { return e.info, nil }
+
+// memFile is a regular file of a MemFS opened
+// with OpenFile or Open.
+// All operations are guarded by the MemFS lock.
+type memFile struct {
+	fsys   *MemFS
+	name   string
+	node   *memNode
+	flag   Flag
+	offset int64
+	closed bool
+}
+
+var (
+	_ SeekWriter    = &memFile{}
+	_ WriterAtFile  = &memFile{}
+	_ TruncaterFile = &memFile{}
+	_ SyncerFile    = &memFile{}
+	_ io.ReaderAt   = &memFile{}
+)
+
+// Stat implements fs.File
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fsys.mu.RLock()
+	defer f.fsys.mu.RUnlock()
+
+	if f.closed {
+		return nil, &fs.PathError{Op: "Stat", Path: f.name, Err: fs.ErrClosed}
+	}
+	return f.node.info(path.Base(f.name)), nil
+}
+
+// Read implements fs.File
+func (f *memFile) Read(buf []byte) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+
+	n, err := f.readAt("Read", buf, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt
+func (f *memFile) ReadAt(buf []byte, off int64) (int, error) {
+	f.fsys.mu.RLock()
+	defer f.fsys.mu.RUnlock()
+
+	if off < 0 {
+		return 0, &fs.PathError{Op: "ReadAt", Path: f.name, Err: fs.ErrInvalid}
+	}
+	n, err := f.readAt("ReadAt", buf, off)
+	if err == nil && n < len(buf) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// readAt reads from buf starting at off.
+// The caller must hold the lock.
+func (f *memFile) readAt(op string, buf []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.flag.access() == WriteOnly {
+		return 0, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrInvalid}
+	}
+	if off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	return copy(buf, f.node.data[off:]), nil
+}
+
+// Write implements io.Writer
+func (f *memFile) Write(buf []byte) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+
+	if err := f.checkWrite("Write"); err != nil {
+		return 0, err
+	}
+	if f.flag&Append != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	n := f.writeAt(buf, f.offset)
+	f.offset += int64(n)
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt
+func (f *memFile) WriteAt(buf []byte, off int64) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+
+	if err := f.checkWrite("WriteAt"); err != nil {
+		return 0, err
+	}
+	if off < 0 || f.flag&Append != 0 {
+		return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: fs.ErrInvalid}
+	}
+	return f.writeAt(buf, off), nil
+}
+
+// Truncate changes the size of the file.
+// It does not change the offset.
+func (f *memFile) Truncate(size int64) error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+
+	if err := f.checkWrite("Truncate"); err != nil {
+		return err
+	}
+	if size < 0 {
+		return &fs.PathError{Op: "Truncate", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if size > int64(len(f.node.data)) {
+		f.grow(size)
+	} else {
+		f.node.data = f.node.data[:size]
+	}
+	f.node.modTime = time.Now()
+	return nil
+}
+
+// Sync does nothing, since a MemFS has
+// no stable storage to commit the file to.
+func (f *memFile) Sync() error {
+	f.fsys.mu.RLock()
+	defer f.fsys.mu.RUnlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "Sync", Path: f.name, Err: fs.ErrClosed}
+	}
+	return nil
+}
+
+// checkWrite checks that f can be written.
+// The caller must hold the lock.
+func (f *memFile) checkWrite(op string) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.flag.access() == ReadOnly {
+		return &fs.PathError{Op: op, Path: f.name, Err: ErrReadOnlyFile}
+	}
+	return nil
+}
+
+// writeAt writes buf starting at off,
+// growing the file if needed.
+// The caller must hold the write lock.
+func (f *memFile) writeAt(buf []byte, off int64) int {
+	end := off + int64(len(buf))
+	if end > int64(len(f.node.data)) {
+		f.grow(end)
+	}
+	n := copy(f.node.data[off:], buf)
+	f.node.modTime = time.Now()
+	return n
+}
+
+// grow extends the file to size bytes,
+// filling the new space with zeros.
+// The caller must hold the write lock.
+func (f *memFile) grow(size int64) {
+	if size > int64(cap(f.node.data)) {
+		data := make([]byte, size, 2*size)
+		copy(data, f.node.data)
+		f.node.data = data
+		return
+	}
+	old := len(f.node.data)
+	f.node.data = f.node.data[:size]
+	for i := old; i < int(size); i++ {
+		f.node.data[i] = 0
+	}
+}
+
+// Seek implements io.Seeker
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+
+	if f.closed {
+		return 0, &fs.PathError{Op: "Seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	default:
+		return 0, &fs.PathError{Op: "Seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "Seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Close implements fs.File
+func (f *memFile) Close() error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "Close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
+
+// memDir is a directory of a MemFS opened
+// with OpenFile or Open.
+// Its entries are a snapshot taken when the
+// directory is opened.
+type memDir struct {
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ fs.ReadDirFile = &memDir{}
+var _ FileWriter = &memDir{}
+
+// Stat implements fs.File
+func (d *memDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+// Read implements fs.File
+func (d *memDir) Read(buf []byte) (int, error) {
+	return 0, &fs.PathError{Op: "Read", Path: d.name, Err: ErrIsDir}
+}
+
+// Write implements io.Writer
+func (d *memDir) Write(buf []byte) (int, error) {
+	return 0, &fs.PathError{Op: "Write", Path: d.name, Err: ErrIsDir}
+}
+
+// Close implements fs.File
+func (d *memDir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	n := len(d.entries) - d.offset
+	if n == 0 && count > 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && n > count {
+		n = count
+	}
+	list := make([]fs.DirEntry, n)
+	copy(list, d.entries[d.offset:d.offset+n])
+	d.offset += n
+	return list, nil
+}
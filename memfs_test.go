@@ -0,0 +1,174 @@
+package writefs_test
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFS(t *testing.T) {
+	data := []byte("ciao")
+
+	t.Run("is seeded from a MapFS", func(t *testing.T) {
+		fsys := writefs.NewMemFS(fstest.MapFS{
+			"dir1/file2": &fstest.MapFile{Data: data, Mode: 0644},
+		})
+
+		actual, err := fs.ReadFile(fsys, "dir1/file2")
+		require.NoError(t, err)
+		assert.Equal(t, data, actual)
+
+		info, err := fs.Stat(fsys, "dir1")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+
+		assert.NoError(t, fstest.TestFS(fsys, "dir1/file2"))
+	})
+
+	t.Run("zero value is usable", func(t *testing.T) {
+		var fsys writefs.MemFS
+		entries, err := fs.ReadDir(&fsys, ".")
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+
+		_, err = writefs.WriteFile(&fsys, "file1", data)
+		assert.NoError(t, err)
+	})
+
+	t.Run("snapshots to a MapFS", func(t *testing.T) {
+		fsys := writefs.NewMemFS(nil)
+		_, err := writefs.WriteFile(fsys, "file1", data)
+		require.NoError(t, err)
+
+		snapshot := fsys.MapFS()
+		_, err = writefs.WriteFile(fsys, "file1", []byte("changed"))
+		require.NoError(t, err)
+
+		require.Contains(t, snapshot, "file1")
+		assert.Equal(t, data, snapshot["file1"].Data)
+	})
+
+	t.Run("honors Append", func(t *testing.T) {
+		fsys := writefs.NewMemFS(fstest.MapFS{"file1": &fstest.MapFile{Data: data}})
+		f, err := fsys.OpenFile("file1", writefs.WriteOnly|writefs.Append, 0)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		actual, err := fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "ciaociao", string(actual))
+	})
+
+	t.Run("honors Exclusive", func(t *testing.T) {
+		fsys := writefs.NewMemFS(fstest.MapFS{"file1": &fstest.MapFile{Data: data}})
+		_, err := fsys.OpenFile("file1", writefs.WriteOnly|writefs.Create|writefs.Exclusive, 0644)
+		assert.ErrorIs(t, err, fs.ErrExist)
+	})
+
+	t.Run("requires Create for missing files", func(t *testing.T) {
+		fsys := writefs.NewMemFS(nil)
+		_, err := fsys.OpenFile("file1", writefs.WriteOnly, 0644)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = fsys.OpenFile("dir1/file1", writefs.WriteOnly|writefs.Create, 0644)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("reads, writes and seeks", func(t *testing.T) {
+		fsys := writefs.NewMemFS(nil)
+		f, err := fsys.OpenFile("file1", writefs.ReadWrite|writefs.Create|writefs.Synchronous, 0644)
+		require.NoError(t, err)
+		defer f.Close()
+
+		_, err = f.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		seeker, ok := f.(io.Seeker)
+		require.True(t, ok)
+		_, err = seeker.Seek(6, io.SeekStart)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+
+		_, err = seeker.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		actual, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "hello ciaod", string(actual))
+
+		info, err := f.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), info.Size())
+		assert.Equal(t, fs.FileMode(0644), info.Mode())
+	})
+
+	t.Run("refuses writes on read only files", func(t *testing.T) {
+		fsys := writefs.NewMemFS(fstest.MapFS{"file1": &fstest.MapFile{Data: data}})
+		f, err := fsys.OpenFile("file1", writefs.ReadOnly, 0)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+
+	t.Run("creates directories recursively", func(t *testing.T) {
+		fsys := writefs.NewMemFS(nil)
+		f, err := fsys.OpenFile("dir1/dir2", writefs.Create, fs.ModeDir|0755)
+		require.NoError(t, err)
+		assert.Nil(t, f)
+
+		info, err := fsys.Stat("dir1")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+
+		_, err = fsys.OpenFile("dir1/dir2", writefs.Create, fs.ModeDir|0755)
+		assert.NoError(t, err)
+		_, err = fsys.OpenFile("dir1/dir2", writefs.Create|writefs.Exclusive, fs.ModeDir|0755)
+		assert.ErrorIs(t, err, fs.ErrExist)
+	})
+
+	t.Run("deletes recursively", func(t *testing.T) {
+		fsys := writefs.NewMemFS(fstest.MapFS{
+			"dir1/dir2/file3": &fstest.MapFile{Data: data},
+			"dir10":           &fstest.MapFile{Data: data},
+		})
+		f, err := fsys.OpenFile("dir1", writefs.Truncate, 0)
+		require.NoError(t, err)
+		assert.Nil(t, f)
+
+		assert.Equal(t, []string{"dir10"}, mapKeys(fsys.MapFS()))
+
+		_, err = fsys.OpenFile("dir1", writefs.Truncate, 0)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		fsys := writefs.NewMemFS(nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := writefs.WriteFile(fsys, "file1", data)
+				assert.NoError(t, err)
+				_, err = fs.ReadFile(fsys, "file1")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func mapKeys(m fstest.MapFS) []string {
+	var keys []string
+	for name := range m {
+		keys = append(keys, name)
+	}
+	return keys
+}
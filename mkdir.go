@@ -0,0 +1,108 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// MkDirFS is the interface implemented by a file system
+// that provides an optimized implementation of MkDir.
+type MkDirFS interface {
+	fs.FS
+
+	// MkDir creates a new directory with the specified name
+	// and permission bits. The parent directory must exist.
+	// If there is an error, it will be of type *fs.PathError.
+	MkDir(name string, perm fs.FileMode) error
+}
+
+// MkDir creates a new directory with the specified name
+// and permission bits. The parent directory must exist,
+// while name must not.
+//
+// If fsys implements MkDirFS, MkDir calls fsys.MkDir.
+// Otherwise, if fsys implements WriteFS, MkDir checks that the
+// parent directory exists and then creates name by calling
+// OpenFile with Create|Exclusive flags and fs.ModeDir perm.
+//
+// If there is an error, it will be of type *fs.PathError.
+func MkDir(fsys fs.FS, name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "MkDir", Path: name, Err: err}
+	}
+
+	if fsys, ok := fsys.(MkDirFS); ok {
+		if err := fsys.MkDir(name, perm); err != nil {
+			return opPathError("MkDir", name, err)
+		}
+		return nil
+	}
+
+	parent, err := fs.Stat(fsys, path.Dir(name))
+	if err != nil {
+		return opPathError("MkDir", name, err)
+	}
+	if !parent.IsDir() {
+		return &fs.PathError{Op: "MkDir", Path: path.Dir(name), Err: ErrNotDir}
+	}
+
+	_, err = OpenFile(fsys, name, Create|Exclusive, perm|fs.ModeDir)
+	if err != nil {
+		return opPathError("MkDir", name, err)
+	}
+	return nil
+}
+
+// MkdirAll creates a directory named name,
+// along with any necessary parents.
+// If name is already a directory, MkdirAll does nothing
+// and returns nil.
+//
+// If fsys implements MkDirFS, MkdirAll calls fsys.MkDir
+// for every missing directory.
+// Otherwise, if fsys implements WriteFS, MkdirAll calls
+// OpenFile with Create flag and fs.ModeDir perm.
+//
+// If there is an error, it will be of type *fs.PathError.
+func MkdirAll(fsys fs.FS, name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "MkdirAll", Path: name, Err: err}
+	}
+
+	mfs, ok := fsys.(MkDirFS)
+	if !ok {
+		_, err := OpenFile(fsys, name, Create, perm|fs.ModeDir)
+		if err != nil {
+			return opPathError("MkdirAll", name, err)
+		}
+		return nil
+	}
+
+	if name == "." {
+		return nil
+	}
+	parts := strings.Split(name, "/")
+	for i := range parts {
+		dir := strings.Join(parts[:i+1], "/")
+		info, err := fs.Stat(mfs, dir)
+		if err == nil {
+			if !info.IsDir() {
+				return &fs.PathError{Op: "MkdirAll", Path: dir, Err: ErrNotDir}
+			}
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return opPathError("MkdirAll", name, err)
+		}
+		err = mfs.MkDir(dir, perm)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return opPathError("MkdirAll", name, err)
+		}
+	}
+	return nil
+}
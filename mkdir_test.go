@@ -0,0 +1,87 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	mockfs "github.com/parrogo/writefs/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMkDir(t *testing.T) {
+	t.Run("calls fsys.MkDir when fsys implements MkDirFS", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		testfs.On("MkDir", "dir1", fs.FileMode(0755)).Return(nil)
+
+		assert.NoError(t, writefs.MkDir(&testfs, "dir1", 0755))
+		testfs.AssertExpectations(t)
+	})
+
+	t.Run("wraps fsys.MkDir errors", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		testfs.On("MkDir", "dir1", fs.FileMode(0755)).Return(errors.New("expected"))
+
+		err := writefs.MkDir(&testfs, "dir1", 0755)
+		assert.Equal(t, "MkDir dir1: expected", err.Error())
+		testfs.AssertExpectations(t)
+	})
+
+	for name, fsys := range map[string]func() writefs.WriteFS{
+		"MkDirFS":  func() writefs.WriteFS { return newTreeFS() },
+		"fallback": func() writefs.WriteFS { return openFileOnlyFS{newTreeFS()} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("creates directories", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.MkDir(fsys, "dir1/dir5", 0755))
+				info, err := fs.Stat(fsys, "dir1/dir5")
+				require.NoError(t, err)
+				assert.True(t, info.IsDir())
+			})
+
+			t.Run("refuses existing directories", func(t *testing.T) {
+				err := writefs.MkDir(fsys(), "dir1", 0755)
+				assert.ErrorIs(t, err, fs.ErrExist)
+				var perr *fs.PathError
+				require.ErrorAs(t, err, &perr)
+				assert.Equal(t, "MkDir", perr.Op)
+			})
+
+			t.Run("refuses missing parents", func(t *testing.T) {
+				err := writefs.MkDir(fsys(), "dir5/dir6", 0755)
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+			})
+
+			t.Run("MkdirAll creates parents", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.MkdirAll(fsys, "dir1/dir5/dir6", 0755))
+				info, err := fs.Stat(fsys, "dir1/dir5/dir6")
+				require.NoError(t, err)
+				assert.True(t, info.IsDir())
+			})
+
+			t.Run("MkdirAll ignores existing directories", func(t *testing.T) {
+				assert.NoError(t, writefs.MkdirAll(fsys(), "dir1/dir2", 0755))
+			})
+
+			t.Run("MkdirAll refuses files in the path", func(t *testing.T) {
+				err := writefs.MkdirAll(fsys(), "file1/dir5", 0755)
+				assert.Error(t, err)
+				var perr *fs.PathError
+				require.ErrorAs(t, err, &perr)
+				assert.Equal(t, "MkdirAll", perr.Op)
+			})
+		})
+	}
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		err := writefs.MkDir(newTreeFS(), "/", 0755)
+		assert.Equal(t, "MkDir /: invalid argument name: not a valid path", err.Error())
+
+		err = writefs.MkdirAll(newTreeFS(), "/", 0755)
+		assert.Equal(t, "MkdirAll /: invalid argument name: not a valid path", err.Error())
+	})
+}
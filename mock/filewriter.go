@@ -22,7 +22,13 @@ type FileWriter struct {
 	mock.Mock
 }
 
-var _ writefs.FileWriter = &FileWriter{}
+var (
+	_ writefs.FileWriter    = &FileWriter{}
+	_ writefs.SeekWriter    = &FileWriter{}
+	_ writefs.WriterAtFile  = &FileWriter{}
+	_ writefs.TruncaterFile = &FileWriter{}
+	_ writefs.SyncerFile    = &FileWriter{}
+)
 
 // Close implements fs.Close
 func (w *FileWriter) Close() error {
@@ -50,3 +56,27 @@ func (w *FileWriter) Stat() (fs.FileInfo, error) {
 	res2, _ := res.(fs.FileInfo)
 	return res2, args.Error(1)
 }
+
+// Seek implements io.Seeker
+func (w *FileWriter) Seek(offset int64, whence int) (int64, error) {
+	args := w.Called(offset, whence)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+// WriteAt implements io.WriterAt
+func (w *FileWriter) WriteAt(buf []byte, off int64) (int, error) {
+	args := w.Called(buf, off)
+	return args.Int(0), args.Error(1)
+}
+
+// Truncate implements writefs.TruncaterFile
+func (w *FileWriter) Truncate(size int64) error {
+	args := w.Called(size)
+	return args.Error(0)
+}
+
+// Sync implements writefs.SyncerFile
+func (w *FileWriter) Sync() error {
+	args := w.Called()
+	return args.Error(0)
+}
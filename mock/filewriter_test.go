@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"io"
 	"io/fs"
 	"testing"
 	"testing/fstest"
@@ -55,6 +56,39 @@ func TestFileWriter(t *testing.T) {
 		w.AssertExpectations(t)
 	})
 
+	t.Run("Seek", func(t *testing.T) {
+		w := &FileWriter{}
+		w.On("Seek", int64(4), io.SeekStart).Return(4, nil)
+		pos, err := w.Seek(4, io.SeekStart)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), pos)
+		w.AssertExpectations(t)
+	})
+
+	t.Run("WriteAt", func(t *testing.T) {
+		w := &FileWriter{}
+		w.On("WriteAt", data, int64(4)).Return(42, nil)
+		n, err := w.WriteAt(data, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, n)
+		w.AssertExpectations(t)
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		w := &FileWriter{}
+		w.On("Truncate", int64(4)).Return(nil)
+		err := w.Truncate(4)
+		assert.NoError(t, err)
+		w.AssertExpectations(t)
+	})
+
+	t.Run("Sync", func(t *testing.T) {
+		w := &FileWriter{}
+		w.On("Sync").Return(nil)
+		err := w.Sync()
+		assert.NoError(t, err)
+		w.AssertExpectations(t)
+	})
 }
 
 func newMemDirInfo(name string) fs.FileInfo {
@@ -19,13 +19,14 @@ var (
 	_ fs.ReadDirFS  = &FS{}
 	_ fs.GlobFS     = &FS{}
 
-	_ writefs.WriteFS = &FS{}
-	//_ writefs.RemoveFS = &FS{}
-	//_ writefs.MkDirFS  = &FS{}
+	_ writefs.WriteFS  = &FS{}
+	_ writefs.RemoveFS = &FS{}
+	_ writefs.MkDirFS  = &FS{}
+	_ writefs.RenameFS = &FS{}
 )
 
 // OpenFile implements writefs.WriteFS
-func (fsys *FS) OpenFile(name string, flag int, perm fs.FileMode) (writefs.FileWriter, error) {
+func (fsys *FS) OpenFile(name string, flag writefs.Flag, perm fs.FileMode) (writefs.FileWriter, error) {
 	args := fsys.Called(name, flag, perm)
 	res := args.Get(0)
 	res2, _ := res.(writefs.FileWriter)
@@ -44,6 +45,12 @@ func (fsys *FS) Remove(name string) error {
 	return args.Error(0)
 }
 
+// Rename implements writefs.RenameFS
+func (fsys *FS) Rename(oldname, newname string) error {
+	args := fsys.Called(oldname, newname)
+	return args.Error(0)
+}
+
 // Stat implements fs.StatFS
 func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
 	args := fsys.Called(name)
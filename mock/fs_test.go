@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/parrogo/writefs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -56,10 +57,21 @@ func TestFS(t *testing.T) {
 		fsys.AssertExpectations(t)
 	})
 
+	t.Run("Rename", func(t *testing.T) {
+		fsys := &FS{}
+
+		fsys.On("Rename", "dir1", "dir2").Return(nil)
+
+		err := fsys.Rename("dir1", "dir2")
+		require.NoError(t, err)
+
+		fsys.AssertExpectations(t)
+	})
+
 	t.Run("OpenFile", func(t *testing.T) {
 		fsys := &FS{}
 
-		fsys.On("OpenFile", "dir1", 22, fs.FileMode(12)).Return(nil, nil)
+		fsys.On("OpenFile", "dir1", writefs.Flag(22), fs.FileMode(12)).Return(nil, nil)
 
 		f, err := fsys.OpenFile("dir1", 22, fs.FileMode(12))
 		require.NoError(t, err)
@@ -0,0 +1,373 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Mount is a WriteFS that routes every name to one of
+// multiple file systems, each one mounted on a prefix.
+//
+// Names are resolved using the file system mounted on the
+// longest prefix matching name, with the prefix removed.
+// The file system mounted on "." receives the names
+// that do not match any other prefix.
+//
+// Parent directories of mount points are synthesized when
+// the file system that should contain them lacks them, and
+// directory listings include mount points. Creating a synthesized
+// directory with the Create convention creates it on the mounted
+// file system, if it is writable, and otherwise does nothing.
+//
+// Write operations, including the Truncate and Create
+// conventions, are forwarded to the mounted file system.
+// They fail with a *fs.PathError wrapping fs.ErrPermission
+// when the mounted file system does not implement WriteFS,
+// when no file system is mounted for the name, or when
+// they would delete a mount point or one of its parents.
+//
+// Rename is forwarded to the mounted file system when both
+// names belong to it, and fails with an error wrapping
+// ErrCrossDevice otherwise, so that the Rename function
+// falls back to copying files between mounts.
+//
+// The zero value is an empty Mount ready to use.
+type Mount struct {
+	mu     sync.RWMutex
+	mounts map[string]fs.FS
+}
+
+var (
+	_ WriteFS        = &Mount{}
+	_ fs.StatFS      = &Mount{}
+	_ fs.ReadDirFS   = &Mount{}
+	_ RenameFS       = &Mount{}
+	_ linkFS         = &Mount{}
+	_ CapabilitiesFS = &Mount{}
+)
+
+// Mount attaches fsys to prefix, replacing the file
+// system previously mounted there, if any.
+// If fsys is nil, the file system mounted on prefix
+// is removed.
+//
+// If there is an error, it will be of type *fs.PathError.
+func (m *Mount) Mount(prefix string, fsys fs.FS) error {
+	if !fs.ValidPath(prefix) {
+		err := fmt.Errorf("%w prefix: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "Mount", Path: prefix, Err: err}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fsys == nil {
+		delete(m.mounts, prefix)
+		return nil
+	}
+	if m.mounts == nil {
+		m.mounts = map[string]fs.FS{}
+	}
+	m.mounts[prefix] = fsys
+	return nil
+}
+
+// mountPoint describes how a name is resolved.
+type mountPoint struct {
+	// fsys is the file system mounted on the longest
+	// prefix matching name, or nil if there is none.
+	fsys fs.FS
+	// prefix is the prefix fsys is mounted on.
+	prefix string
+	// rel is name relative to prefix.
+	rel string
+	// children contains the names of the children of name
+	// that lead to other mount points.
+	children []string
+}
+
+// lookup resolves name to a mountPoint.
+func (m *Mount) lookup(name string) mountPoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var mp mountPoint
+	for prefix := name; ; prefix = path.Dir(prefix) {
+		if fsys, ok := m.mounts[prefix]; ok {
+			mp.fsys = fsys
+			mp.prefix = prefix
+			mp.rel = "."
+			if name != prefix {
+				mp.rel = strings.TrimPrefix(name, prefix+"/")
+				if prefix == "." {
+					mp.rel = name
+				}
+			}
+			break
+		}
+		if prefix == "." {
+			break
+		}
+	}
+
+	seen := map[string]bool{}
+	for prefix := range m.mounts {
+		var rest string
+		switch {
+		case prefix == name:
+			continue
+		case name == ".":
+			rest = prefix
+		case strings.HasPrefix(prefix, name+"/"):
+			rest = prefix[len(name)+1:]
+		default:
+			continue
+		}
+		child := strings.SplitN(rest, "/", 2)[0]
+		if !seen[child] {
+			seen[child] = true
+			mp.children = append(mp.children, child)
+		}
+	}
+	return mp
+}
+
+// fixErr rewrites the path of a *fs.PathError
+// returned by the file system mounted on prefix.
+func (mp mountPoint) fixErr(err error) error {
+	var perr *fs.PathError
+	if errors.As(err, &perr) && fs.ValidPath(perr.Path) {
+		return &fs.PathError{Op: perr.Op, Path: path.Join(mp.prefix, perr.Path), Err: perr.Err}
+	}
+	return err
+}
+
+// synthesized reports whether name, that must exist,
+// is only a parent of other mount points.
+func (mp mountPoint) synthesized() bool {
+	if mp.fsys == nil {
+		return true
+	}
+	_, err := fs.Stat(mp.fsys, mp.rel)
+	return err != nil
+}
+
+// writable reports whether fsys supports write operations.
+func writable(fsys fs.FS) bool {
+	switch fsys.(type) {
+	case WriteFS, LegacyWriteFS:
+		return true
+	}
+	return false
+}
+
+// mountInfo renames the fs.FileInfo of
+// the root of a mounted file system.
+type mountInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i mountInfo) Name() string { return i.name }
+
+// stat returns the fs.FileInfo of name.
+func (m *Mount) stat(op string, name string, mp mountPoint) (fs.FileInfo, error) {
+	if mp.fsys != nil {
+		info, err := fs.Stat(mp.fsys, mp.rel)
+		if err == nil {
+			if mp.rel == "." {
+				info = mountInfo{FileInfo: info, name: path.Base(name)}
+			}
+			return info, nil
+		}
+		if len(mp.children) == 0 || !errors.Is(err, fs.ErrNotExist) {
+			return nil, mp.fixErr(err)
+		}
+	}
+	if len(mp.children) > 0 {
+		return &memFileInfo{name: path.Base(name), mode: fs.ModeDir | 0555}, nil
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// entries returns the sorted list of children
+// of directory name, including mount points.
+func (m *Mount) entries(name string, mp mountPoint) ([]fs.DirEntry, error) {
+	merged := map[string]fs.DirEntry{}
+	if mp.fsys != nil {
+		list, err := fs.ReadDir(mp.fsys, mp.rel)
+		if err != nil && (len(mp.children) == 0 || !errors.Is(err, fs.ErrNotExist)) {
+			return nil, mp.fixErr(err)
+		}
+		for _, entry := range list {
+			merged[entry.Name()] = entry
+		}
+	}
+	for _, child := range mp.children {
+		childName := path.Join(name, child)
+		info, err := m.stat("ReadDir", childName, m.lookup(childName))
+		if err != nil {
+			return nil, err
+		}
+		merged[child] = memDirEntry{info}
+	}
+	return sortedEntries(merged), nil
+}
+
+// Capabilities implements CapabilitiesFS.
+// A capability is reported when at least one of the mounted
+// file systems supports it, limited to the ones Mount forwards.
+func (m *Mount) Capabilities() Capability {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var caps Capability
+	for _, fsys := range m.mounts {
+		caps |= Capabilities(fsys)
+	}
+	return caps & wrapperCaps
+}
+
+// Open implements fs.FS
+func (m *Mount) Open(name string) (fs.File, error) {
+	return m.OpenFile(name, ReadOnly, 0)
+}
+
+// Stat implements fs.StatFS
+func (m *Mount) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "Stat", Path: name, Err: fs.ErrInvalid}
+	}
+	return m.stat("Stat", name, m.lookup(name))
+}
+
+// Lstat returns a fs.FileInfo describing the named file,
+// without following it if it is a symbolic link.
+func (m *Mount) Lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "Lstat", Path: name, Err: fs.ErrInvalid}
+	}
+	mp := m.lookup(name)
+	if mp.fsys != nil && mp.rel != "." {
+		info, err := Lstat(mp.fsys, mp.rel)
+		if err == nil || len(mp.children) == 0 || !errors.Is(err, fs.ErrNotExist) {
+			return info, mp.fixErr(err)
+		}
+	}
+	return m.stat("Lstat", name, mp)
+}
+
+// Readlink returns the target of the named symbolic link.
+func (m *Mount) Readlink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "Readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	mp := m.lookup(name)
+	if mp.fsys == nil {
+		return "", &fs.PathError{Op: "Readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	target, err := Readlink(mp.fsys, mp.rel)
+	return target, mp.fixErr(err)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (m *Mount) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "ReadDir", Path: name, Err: fs.ErrInvalid}
+	}
+	mp := m.lookup(name)
+	if mp.fsys == nil && len(mp.children) == 0 {
+		return nil, &fs.PathError{Op: "ReadDir", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.entries(name, mp)
+}
+
+// OpenFile implements WriteFS
+func (m *Mount) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+	mp := m.lookup(name)
+
+	switch {
+	case isMkdir(flag, perm):
+		if info, err := m.stat("OpenFile", name, mp); err == nil {
+			if flag&Exclusive != 0 || !info.IsDir() {
+				return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrExist}
+			}
+			if !mp.synthesized() || mp.fsys == nil || !writable(mp.fsys) {
+				return nil, nil
+			}
+			// name only exists as a parent of mount points:
+			// create it on the mounted file system, so
+			// that files can be created in it
+		}
+	case isRemove(flag):
+		if mp.rel == "." || len(mp.children) > 0 {
+			err := fmt.Errorf("%w name: contains a mount point", fs.ErrPermission)
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: err}
+		}
+	case flag.access() == ReadOnly && flag&(Create|Truncate) == 0:
+		info, err := m.stat("OpenFile", name, mp)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			entries, err := m.entries(name, mp)
+			if err != nil {
+				return nil, err
+			}
+			return &memDir{name: name, info: info, entries: entries}, nil
+		}
+		if !writable(mp.fsys) {
+			file, err := openFileReadOnly(mp.fsys, mp.rel)
+			return file, mp.fixErr(err)
+		}
+		file, err := OpenFile(mp.fsys, mp.rel, flag, perm)
+		return file, mp.fixErr(err)
+	}
+
+	if mp.fsys == nil || !writable(mp.fsys) {
+		err := fmt.Errorf("%w name: not on a writable mount", fs.ErrPermission)
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: err}
+	}
+	file, err := OpenFile(mp.fsys, mp.rel, flag, perm)
+	return file, mp.fixErr(err)
+}
+
+// Rename implements RenameFS.
+// It returns an error wrapping errUnsupported if the
+// file system mounted for both names does not implement RenameFS.
+func (m *Mount) Rename(oldname, newname string) error {
+	for _, name := range []string{oldname, newname} {
+		if !fs.ValidPath(name) || name == "." {
+			return &fs.PathError{Op: "Rename", Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	oldmp, newmp := m.lookup(oldname), m.lookup(newname)
+
+	for _, mp := range []struct {
+		name string
+		mountPoint
+	}{{oldname, oldmp}, {newname, newmp}} {
+		if mp.rel == "." || len(mp.children) > 0 {
+			err := fmt.Errorf("%w name: contains a mount point", fs.ErrPermission)
+			return &fs.PathError{Op: "Rename", Path: mp.name, Err: err}
+		}
+		if mp.fsys == nil || !writable(mp.fsys) {
+			err := fmt.Errorf("%w name: not on a writable mount", fs.ErrPermission)
+			return &fs.PathError{Op: "Rename", Path: mp.name, Err: err}
+		}
+	}
+
+	if oldmp.prefix != newmp.prefix {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: ErrCrossDevice}
+	}
+	rfs, ok := oldmp.fsys.(RenameFS)
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: errUnsupported}
+	}
+	return oldmp.fixErr(rfs.Rename(oldmp.rel, newmp.rel))
+}
@@ -0,0 +1,190 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMount returns a Mount with a read-only root,
+// a MemFS on "cache" and a DirFS on "var/data".
+func newTestMount(t *testing.T) (*writefs.Mount, *writefs.MemFS, writefs.WriteFS) {
+	cache := newTreeFS()
+	data := newTreeDirFS(t)
+	m := &writefs.Mount{}
+	require.NoError(t, m.Mount(".", fstest.MapFS{
+		"readme": &fstest.MapFile{Data: []byte("root")},
+	}))
+	require.NoError(t, m.Mount("cache", cache))
+	require.NoError(t, m.Mount("var/data", data))
+	return m, cache, data
+}
+
+func entryNames(t *testing.T, fsys fs.FS, name string) []string {
+	entries, err := fs.ReadDir(fsys, name)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestMount(t *testing.T) {
+	t.Run("dispatches to the longest prefix", func(t *testing.T) {
+		m, cache, data := newTestMount(t)
+		require.NoError(t, m.Mount("cache/dir1", writefs.NewMemFS(nil)))
+
+		_, err := writefs.WriteFile(m, "cache/file5", []byte("cache"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(m, "cache/dir1/file5", []byte("nested"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(m, "var/data/file5", []byte("data"))
+		require.NoError(t, err)
+
+		buf, err := fs.ReadFile(cache, "file5")
+		require.NoError(t, err)
+		assert.Equal(t, "cache", string(buf))
+		_, err = fs.Stat(cache, "dir1/file5")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		buf, err = fs.ReadFile(data, "file5")
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(buf))
+		buf, err = fs.ReadFile(m, "readme")
+		require.NoError(t, err)
+		assert.Equal(t, "root", string(buf))
+	})
+
+	t.Run("synthesizes parents of mount points", func(t *testing.T) {
+		m, _, _ := newTestMount(t)
+		info, err := fs.Stat(m, "var")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+		assert.Equal(t, []string{"cache", "readme", "var"}, entryNames(t, m, "."))
+		assert.Equal(t, []string{"data"}, entryNames(t, m, "var"))
+		assert.Equal(t, []string{"dir1", "dir4", "file1"}, entryNames(t, m, "var/data"))
+		assert.NoError(t, fstest.TestFS(m, "readme", "cache/file1", "var/data/dir1/file2"))
+	})
+
+	t.Run("works without a root mount", func(t *testing.T) {
+		m := &writefs.Mount{}
+		require.NoError(t, m.Mount("a/b", newTreeFS()))
+		assert.Equal(t, []string{"a"}, entryNames(t, m, "."))
+		_, err := fs.Stat(m, "c")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = writefs.WriteFile(m, "c", []byte("hello"))
+		assert.ErrorIs(t, err, fs.ErrPermission)
+		assert.NoError(t, fstest.TestFS(m, "a/b/file1"))
+	})
+
+	t.Run("rewrites error paths", func(t *testing.T) {
+		m, _, _ := newTestMount(t)
+		_, err := m.Open("cache/notexists")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		var perr *fs.PathError
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, "cache/notexists", perr.Path)
+	})
+
+	t.Run("forwards remove and mkdir conventions", func(t *testing.T) {
+		m, cache, _ := newTestMount(t)
+		require.NoError(t, writefs.RemoveAll(m, "cache/dir1"))
+		require.NoError(t, writefs.MkdirAll(m, "cache/dir5/dir6", 0755))
+		assert.ElementsMatch(t, []string{"dir4", "dir5", "dir5/dir6", "file1"}, mapKeys(cache.MapFS()))
+		assert.NoError(t, writefs.MkdirAll(m, "var/data", 0755))
+	})
+
+	t.Run("accepts synthesized parents on read-only mounts", func(t *testing.T) {
+		m, _, _ := newTestMount(t)
+		assert.NoError(t, writefs.MkdirAll(m, "var", 0755))
+
+		m = &writefs.Mount{}
+		require.NoError(t, m.Mount("a/b", newTreeFS()))
+		assert.NoError(t, writefs.MkdirAll(m, "a", 0755))
+		_, err := writefs.OpenFile(m, "a", writefs.Create|writefs.Exclusive, fs.ModeDir|0755)
+		assert.ErrorIs(t, err, fs.ErrExist)
+		_, err = writefs.WriteFile(m, "a/file1", []byte("hello"))
+		assert.ErrorIs(t, err, fs.ErrPermission)
+	})
+
+	t.Run("creates synthesized parents on writable mounts", func(t *testing.T) {
+		m, _, _ := newTestMount(t)
+		root := writefs.NewMemFS(nil)
+		require.NoError(t, m.Mount(".", root))
+		require.NoError(t, writefs.MkdirAll(m, "var", 0755))
+		_, err := writefs.WriteFile(m, "var/file1", []byte("hello"))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"var", "var/file1"}, mapKeys(root.MapFS()))
+		assert.Equal(t, []string{"data", "file1"}, entryNames(t, m, "var"))
+	})
+
+	for name, fn := range map[string]func(m *writefs.Mount) error{
+		"writes on read-only mounts": func(m *writefs.Mount) error {
+			_, err := writefs.WriteFile(m, "readme", []byte("hello"))
+			return err
+		},
+		"deletes on read-only mounts": func(m *writefs.Mount) error {
+			return writefs.Remove(m, "readme")
+		},
+		"mkdir on read-only mounts": func(m *writefs.Mount) error {
+			return writefs.MkDir(m, "dir5", 0755)
+		},
+		"deleting mount points": func(m *writefs.Mount) error {
+			_, err := writefs.OpenFile(m, "cache", writefs.Truncate, 0)
+			return err
+		},
+		"deleting parents of mount points": func(m *writefs.Mount) error {
+			_, err := writefs.OpenFile(m, "var", writefs.Truncate, 0)
+			return err
+		},
+		"renaming on read-only mounts": func(m *writefs.Mount) error {
+			return m.Rename("readme", "cache/readme")
+		},
+		"renaming mount points": func(m *writefs.Mount) error {
+			return m.Rename("var/data", "cache/data")
+		},
+	} {
+		t.Run("denies "+name, func(t *testing.T) {
+			m, _, _ := newTestMount(t)
+			err := fn(m)
+			assert.ErrorIs(t, err, fs.ErrPermission)
+			var perr *fs.PathError
+			assert.ErrorAs(t, err, &perr)
+		})
+	}
+
+	t.Run("renames within a mount", func(t *testing.T) {
+		m, cache, _ := newTestMount(t)
+		require.NoError(t, m.Rename("cache/file1", "cache/dir1/file5"))
+		assert.ElementsMatch(t, []string{"dir1", "dir1/dir2", "dir1/dir2/file3", "dir1/file2", "dir1/file5", "dir4"}, mapKeys(cache.MapFS()))
+	})
+
+	t.Run("renames across mounts by copying", func(t *testing.T) {
+		m, cache, data := newTestMount(t)
+		err := m.Rename("cache/file1", "var/data/file5")
+		assert.ErrorIs(t, err, writefs.ErrCrossDevice)
+
+		require.NoError(t, writefs.Rename(m, "cache/file1", "var/data/file5"))
+		_, err = fs.Stat(cache, "file1")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		content, err := fs.ReadFile(data, "file5")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(content))
+	})
+
+	t.Run("unmounts with nil fsys", func(t *testing.T) {
+		m, _, _ := newTestMount(t)
+		require.NoError(t, m.Mount("cache", nil))
+		_, err := fs.Stat(m, "cache")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("return PathError for invalid prefix", func(t *testing.T) {
+		err := (&writefs.Mount{}).Mount("cache/", writefs.NewMemFS(nil))
+		assert.Equal(t, "Mount cache/: invalid argument prefix: not a valid path", err.Error())
+	})
+}
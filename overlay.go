@@ -0,0 +1,329 @@
+package writefs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// Overlay returns a WriteFS that combines base, a read-only
+// file system, and upper, a WriteFS where all changes are written.
+//
+// Reads look up names in upper first, and then in base.
+// Directory listings merge the entries of both layers;
+// when a name exists in both of them, the upper one is used.
+//
+// Opening a base file for writing first copies it to upper,
+// together with its parent directories, so that Append and
+// ReadWrite opens see the original content. When Truncate
+// is used, the content is not copied.
+//
+// Deleting a file or a directory using the Truncate convention
+// removes it from upper and records a whiteout, so that base
+// entries with that name, and their content, disappear from
+// Open, Stat and ReadDir. Whiteouts are kept in memory by the
+// returned value: base and upper are never modified to record them.
+func Overlay(base fs.FS, upper WriteFS) WriteFS {
+	return &overlayFS{
+		base:      base,
+		upper:     upper,
+		whiteouts: map[string]bool{},
+	}
+}
+
+type overlayFS struct {
+	base  fs.FS
+	upper WriteFS
+
+	mu        sync.RWMutex
+	whiteouts map[string]bool
+}
+
+var (
+	_ WriteFS        = &overlayFS{}
+	_ fs.StatFS      = &overlayFS{}
+	_ fs.ReadDirFS   = &overlayFS{}
+	_ CapabilitiesFS = &overlayFS{}
+	_ linkFS         = &overlayFS{}
+)
+
+// whitedOut reports whether name, or one of its
+// parents, has been deleted from base.
+func (o *overlayFS) whitedOut(name string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for {
+		if o.whiteouts[name] {
+			return true
+		}
+		if name == "." {
+			return false
+		}
+		name = path.Dir(name)
+	}
+}
+
+// stat returns the fs.FileInfo of name, and
+// reports whether name exists in upper.
+func (o *overlayFS) stat(op string, name string) (fs.FileInfo, bool, error) {
+	if !fs.ValidPath(name) {
+		return nil, false, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := fs.Stat(o.upper, name)
+	if err == nil {
+		return info, true, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, opPathError(op, name, err)
+	}
+	if !o.whitedOut(name) {
+		info, err = fs.Stat(o.base, name)
+		if err == nil {
+			return info, false, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, false, opPathError(op, name, err)
+		}
+	}
+	return nil, false, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// entries returns the sorted list of children
+// of directory name, merging both layers.
+func (o *overlayFS) entries(name string, inUpper bool) ([]fs.DirEntry, error) {
+	merged := map[string]fs.DirEntry{}
+	if !o.whitedOut(name) {
+		if info, err := fs.Stat(o.base, name); err == nil && info.IsDir() {
+			list, err := fs.ReadDir(o.base, name)
+			if err != nil {
+				return nil, opPathError("ReadDir", name, err)
+			}
+			for _, entry := range list {
+				if !o.whitedOut(path.Join(name, entry.Name())) {
+					merged[entry.Name()] = entry
+				}
+			}
+		}
+	}
+	if inUpper {
+		list, err := fs.ReadDir(o.upper, name)
+		if err != nil {
+			return nil, opPathError("ReadDir", name, err)
+		}
+		for _, entry := range list {
+			merged[entry.Name()] = entry
+		}
+	}
+
+	return sortedEntries(merged), nil
+}
+
+// sortedEntries returns the entries
+// of merged, sorted by name.
+func sortedEntries(merged map[string]fs.DirEntry) []fs.DirEntry {
+	res := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		res = append(res, entry)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name() < res[j].Name()
+	})
+	return res
+}
+
+// Capabilities implements CapabilitiesFS.
+// Capabilities of upper are reported, except for
+// the ones overlayFS does not forward.
+func (o *overlayFS) Capabilities() Capability {
+	return Capabilities(o.upper) & (CapWritable | CapRemove | CapMkdir | CapSync | CapTruncate | CapSeek)
+}
+
+// Open implements fs.FS
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	return o.OpenFile(name, ReadOnly, 0)
+}
+
+// Stat implements fs.StatFS
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	info, _, err := o.stat("Stat", name)
+	return info, err
+}
+
+// Lstat returns a fs.FileInfo describing the named file,
+// without following it if it is a symbolic link.
+func (o *overlayFS) Lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "Lstat", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := Lstat(o.upper, name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) || o.whitedOut(name) {
+		return info, err
+	}
+	return Lstat(o.base, name)
+}
+
+// Readlink returns the target of the named symbolic link.
+func (o *overlayFS) Readlink(name string) (string, error) {
+	if _, err := Lstat(o.upper, name); err == nil || o.whitedOut(name) {
+		return Readlink(o.upper, name)
+	}
+	return Readlink(o.base, name)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, inUpper, err := o.stat("ReadDir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "ReadDir", Path: name, Err: ErrNotDir}
+	}
+	return o.entries(name, inUpper)
+}
+
+// OpenFile implements WriteFS
+func (o *overlayFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+	if isMkdir(flag, perm) {
+		return nil, o.mkdir(name, flag, perm)
+	}
+	if isRemove(flag) {
+		return nil, o.remove(name)
+	}
+
+	info, inUpper, err := o.stat("OpenFile", name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	exists := err == nil
+	if exists && flag&Create != 0 && flag&Exclusive != 0 {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrExist}
+	}
+
+	if exists && info.IsDir() {
+		if flag.access() != ReadOnly {
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: ErrIsDir}
+		}
+		entries, err := o.entries(name, inUpper)
+		if err != nil {
+			return nil, err
+		}
+		return &memDir{name: name, info: info, entries: entries}, nil
+	}
+
+	if inUpper {
+		return o.upper.OpenFile(name, flag, perm)
+	}
+
+	if !exists {
+		if flag&Create == 0 {
+			return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrNotExist}
+		}
+		if err := o.copyUpDir(path.Dir(name)); err != nil {
+			return nil, err
+		}
+		return o.upper.OpenFile(name, flag, perm)
+	}
+
+	// name is a file that exists only in base
+	if flag.access() == ReadOnly {
+		file, err := o.base.Open(name)
+		if err != nil {
+			return nil, opPathError("OpenFile", name, err)
+		}
+		return ReadOnlyWriteFile{file}, nil
+	}
+	if err := o.copyUpDir(path.Dir(name)); err != nil {
+		return nil, err
+	}
+	if flag&Truncate != 0 {
+		return o.upper.OpenFile(name, flag|Create, info.Mode().Perm())
+	}
+	if err := copyFile(o.upper, name, o.base, name, CopyOptions{}); err != nil {
+		return nil, opPathError("OpenFile", name, err)
+	}
+	return o.upper.OpenFile(name, flag, perm)
+}
+
+// copyUpDir makes sure that directory dir, and all its
+// parents, exist in upper, creating them with
+// the same permissions they have in base.
+func (o *overlayFS) copyUpDir(dir string) error {
+	info, inUpper, err := o.stat("OpenFile", dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: "OpenFile", Path: dir, Err: ErrNotDir}
+	}
+	if inUpper {
+		return nil
+	}
+	if err := o.copyUpDir(path.Dir(dir)); err != nil {
+		return err
+	}
+	_, err = o.upper.OpenFile(dir, Create, info.Mode().Perm()|fs.ModeDir)
+	return err
+}
+
+// mkdir creates directory name and any missing parent.
+// When flag contains Exclusive, name itself must not exist.
+func (o *overlayFS) mkdir(name string, flag Flag, perm fs.FileMode) error {
+	info, _, err := o.stat("OpenFile", name)
+	if err == nil {
+		if flag&Exclusive != 0 || !info.IsDir() {
+			return &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrExist}
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// find the nearest existing parent,
+	// and copy it up.
+	dir := path.Dir(name)
+	for {
+		_, _, err := o.stat("OpenFile", dir)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		dir = path.Dir(dir)
+	}
+	if err := o.copyUpDir(dir); err != nil {
+		return err
+	}
+	_, err = o.upper.OpenFile(name, flag, perm)
+	return err
+}
+
+// remove deletes name from upper and
+// records a whiteout for base.
+func (o *overlayFS) remove(name string) error {
+	if name == "." {
+		return &fs.PathError{Op: "OpenFile", Path: name, Err: fs.ErrInvalid}
+	}
+	_, inUpper, err := o.stat("OpenFile", name)
+	if err != nil {
+		return err
+	}
+	if inUpper {
+		if _, err := o.upper.OpenFile(name, Truncate, 0); err != nil {
+			return err
+		}
+	}
+	if _, err := fs.Stat(o.base, name); err == nil {
+		o.mu.Lock()
+		o.whiteouts[name] = true
+		o.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOverlayBase() fstest.MapFS {
+	return fstest.MapFS{
+		"dir1/dir2/file3": &fstest.MapFile{Data: []byte("ciao"), Mode: 0600},
+		"dir1/file2":      &fstest.MapFile{Data: []byte("ciao")},
+		"dir4":            &fstest.MapFile{Mode: fs.ModeDir | 0700},
+		"file1":           &fstest.MapFile{Data: []byte("ciao")},
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	t.Run("reads fall through to base", func(t *testing.T) {
+		fsys := writefs.Overlay(newOverlayBase(), writefs.NewMemFS(nil))
+		data, err := fs.ReadFile(fsys, "dir1/dir2/file3")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+		assert.NoError(t, fstest.TestFS(fsys, "file1", "dir1/file2", "dir1/dir2/file3", "dir4"))
+	})
+
+	t.Run("copies files up before writing", func(t *testing.T) {
+		base := newOverlayBase()
+		upper := writefs.NewMemFS(nil)
+		fsys := writefs.Overlay(base, upper)
+
+		_, err := writefs.AppendFile(fsys, "dir1/dir2/file3", []byte(" mondo"))
+		require.NoError(t, err)
+
+		data, err := fs.ReadFile(fsys, "dir1/dir2/file3")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao mondo", string(data))
+		assert.Equal(t, "ciao", string(base["dir1/dir2/file3"].Data))
+
+		info, err := fs.Stat(upper, "dir1/dir2/file3")
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0600), info.Mode())
+		assert.ElementsMatch(t, []string{"dir1", "dir1/dir2", "dir1/dir2/file3"}, mapKeys(upper.MapFS()))
+	})
+
+	t.Run("does not copy content with Truncate", func(t *testing.T) {
+		fsys := writefs.Overlay(newOverlayBase(), writefs.NewMemFS(nil))
+		file, err := writefs.OpenFile(fsys, "file1", writefs.ReadWrite|writefs.Truncate, 0)
+		require.NoError(t, err)
+		info, err := file.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), info.Size())
+		require.NoError(t, file.Close())
+	})
+
+	t.Run("creates files in base directories", func(t *testing.T) {
+		upper := writefs.NewMemFS(nil)
+		fsys := writefs.Overlay(newOverlayBase(), upper)
+		_, err := writefs.WriteFile(fsys, "dir4/file5", []byte("hello"))
+		require.NoError(t, err)
+
+		info, err := fs.Stat(upper, "dir4")
+		require.NoError(t, err)
+		assert.Equal(t, fs.ModeDir|0700, info.Mode())
+
+		entries, err := fs.ReadDir(fsys, ".")
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.Equal(t, []string{"dir1", "dir4", "file1"}, names)
+	})
+
+	t.Run("merges directory listings", func(t *testing.T) {
+		upper := writefs.NewMemFS(fstest.MapFS{
+			"dir1/file5": &fstest.MapFile{Data: []byte("upper")},
+			"file1":      &fstest.MapFile{Data: []byte("upper")},
+		})
+		fsys := writefs.Overlay(newOverlayBase(), upper)
+
+		entries, err := fs.ReadDir(fsys, "dir1")
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.Equal(t, []string{"dir2", "file2", "file5"}, names)
+
+		data, err := fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "upper", string(data))
+		assert.NoError(t, fstest.TestFS(fsys, "file1", "dir1/file2", "dir1/file5", "dir1/dir2/file3"))
+	})
+
+	t.Run("hides deleted base files", func(t *testing.T) {
+		base := newOverlayBase()
+		fsys := writefs.Overlay(base, writefs.NewMemFS(nil))
+		_, err := writefs.WriteFile(fsys, "file1", []byte("hello"))
+		require.NoError(t, err)
+
+		require.NoError(t, writefs.Remove(fsys, "file1"))
+		_, err = fs.Stat(fsys, "file1")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = fsys.Open("file1")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		assert.Contains(t, base, "file1")
+
+		entries, err := fs.ReadDir(fsys, ".")
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("hides content of deleted base directories", func(t *testing.T) {
+		fsys := writefs.Overlay(newOverlayBase(), writefs.NewMemFS(nil))
+		_, err := writefs.OpenFile(fsys, "dir1", writefs.Truncate, 0)
+		require.NoError(t, err)
+		_, err = fs.Stat(fsys, "dir1/dir2/file3")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		require.NoError(t, writefs.MkdirAll(fsys, "dir1/dir2", 0755))
+		entries, err := fs.ReadDir(fsys, "dir1/dir2")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+		assert.NoError(t, fstest.TestFS(fsys, "file1", "dir1/dir2", "dir4"))
+	})
+
+	t.Run("returns ErrNotExist deleting missing files", func(t *testing.T) {
+		fsys := writefs.Overlay(newOverlayBase(), writefs.NewMemFS(nil))
+		_, err := writefs.OpenFile(fsys, "notexists", writefs.Truncate, 0)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("returns ErrExist for Exclusive on base files", func(t *testing.T) {
+		fsys := writefs.Overlay(newOverlayBase(), writefs.NewMemFS(nil))
+		_, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly|writefs.Create|writefs.Exclusive, 0644)
+		assert.ErrorIs(t, err, fs.ErrExist)
+		_, err = writefs.OpenFile(fsys, "dir4", writefs.Create|writefs.Exclusive, fs.ModeDir|0755)
+		assert.ErrorIs(t, err, fs.ErrExist)
+	})
+
+	t.Run("renames base files", func(t *testing.T) {
+		fsys := writefs.Overlay(newOverlayBase(), writefs.NewMemFS(nil))
+		require.NoError(t, writefs.Rename(fsys, "dir1/file2", "dir4/file2"))
+		_, err := fs.Stat(fsys, "dir1/file2")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		data, err := fs.ReadFile(fsys, "dir4/file2")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+	})
+}
@@ -0,0 +1,378 @@
+package writefs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ErrQuotaExceeded is returned, wrapped in a *fs.PathError,
+// by file systems returned by WithQuota when an operation
+// would exceed their limits.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// WithQuota returns a WriteFS that forwards every operation
+// to fsys, limiting the total size of its files to maxBytes,
+// and their number to maxFiles.
+// A negative limit disables the corresponding check.
+//
+// Usage is computed walking fsys when WithQuota is called, and
+// then updated by the operations done through the returned
+// file system: bytes written using FileWriter.Write, files
+// created, truncated using the Truncate flag, deleted using the
+// Truncate convention, or replaced by Rename.
+// Changes done directly on fsys are not accounted.
+// Directories are not counted as files, and their size is ignored.
+// The size of a file opened for writing more than once is shared
+// by all its handles, so that its bytes are counted once.
+//
+// Operations that would exceed a limit fail with a *fs.PathError
+// wrapping ErrQuotaExceeded, without modifying fsys: in particular,
+// Write writes nothing when p does not fit in the remaining bytes.
+// Functions combining several operations keep the effects of the
+// ones that succeeded: WriteFile exceeding maxBytes leaves the
+// file it created, or truncated, empty.
+func WithQuota(fsys WriteFS, maxBytes int64, maxFiles int) WriteFS {
+	q := &quotaFS{
+		fsys:     fsys,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		sizes:    map[string]*quotaSize{},
+	}
+	q.bytes, q.files = treeUsage(fsys, ".")
+	return q
+}
+
+type quotaFS struct {
+	fsys     WriteFS
+	maxBytes int64
+	maxFiles int
+
+	mu    sync.Mutex
+	bytes int64
+	files int
+	// sizes tracks the files opened for writing.
+	sizes map[string]*quotaSize
+}
+
+// quotaSize is the size of a file opened for
+// writing, shared by all of its handles.
+type quotaSize struct {
+	size int64
+	refs int
+}
+
+var (
+	_ WriteFS        = &quotaFS{}
+	_ RenameFS       = &quotaFS{}
+	_ CapabilitiesFS = &quotaFS{}
+	_ linkFS         = &quotaFS{}
+)
+
+// Capabilities implements CapabilitiesFS.
+// Capabilities of the underlying fsys are reported,
+// limited to the ones quotaFS can account for.
+func (q *quotaFS) Capabilities() Capability {
+	return Capabilities(q.fsys) & wrapperCaps
+}
+
+// treeUsage returns the total size and the number
+// of files contained in directory root.
+func treeUsage(fsys fs.FS, root string) (bytes int64, files int) {
+	fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		files++
+		if info, err := d.Info(); err == nil {
+			bytes += info.Size()
+		}
+		return nil
+	})
+	return bytes, files
+}
+
+// usage returns the size and the number of files
+// used by name and, if it is a directory, its content.
+// The caller must hold the lock.
+func (q *quotaFS) usage(name string) (int64, int) {
+	entry, err := dirEntry(q.fsys, name)
+	if err != nil {
+		return 0, 0
+	}
+	if entry.IsDir() {
+		return treeUsage(q.fsys, name)
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return 0, 1
+	}
+	return info.Size(), 1
+}
+
+// Open implements fs.FS
+func (q *quotaFS) Open(name string) (fs.File, error) {
+	return q.fsys.Open(name)
+}
+
+// Lstat returns a fs.FileInfo describing the named file of the
+// underlying fsys, without following it if it is a symbolic link.
+func (q *quotaFS) Lstat(name string) (fs.FileInfo, error) {
+	return Lstat(q.fsys, name)
+}
+
+// Readlink returns the target of the named
+// symbolic link of the underlying fsys.
+func (q *quotaFS) Readlink(name string) (string, error) {
+	return Readlink(q.fsys, name)
+}
+
+// OpenFile implements WriteFS
+func (q *quotaFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	if isMkdir(flag, perm) {
+		return q.fsys.OpenFile(name, flag, perm)
+	}
+	if isRemove(flag) {
+		return nil, q.remove(name)
+	}
+	if flag.access() == ReadOnly && flag&Create == 0 {
+		return q.fsys.OpenFile(name, flag, perm)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	info, err := fs.Stat(q.fsys, name)
+	exists := err == nil
+	if exists && info.IsDir() {
+		return q.fsys.OpenFile(name, flag, perm)
+	}
+	if !exists && q.maxFiles >= 0 && q.files+1 > q.maxFiles {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: ErrQuotaExceeded}
+	}
+
+	file, err := q.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	size := q.sizes[name]
+	if !exists || size == nil {
+		size = &quotaSize{}
+		if exists {
+			size.size = info.Size()
+		}
+	}
+	if !exists {
+		q.files++
+	} else if flag&Truncate != 0 && flag.access() != ReadOnly {
+		q.bytes -= size.size
+		size.size = 0
+	}
+
+	if flag.access() == ReadOnly {
+		return file, nil
+	}
+	size.refs++
+	q.sizes[name] = size
+	return &quotaFile{
+		FileWriter: file,
+		fsys:       q,
+		name:       name,
+		append:     flag&Append != 0,
+		size:       size,
+	}, nil
+}
+
+// remove deletes name using the Truncate
+// convention, and releases its usage.
+func (q *quotaFS) remove(name string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	bytes, files := q.usage(name)
+	if _, err := q.fsys.OpenFile(name, Truncate, 0); err != nil {
+		return err
+	}
+	q.bytes -= bytes
+	q.files -= files
+	q.moveSizes(name, "")
+	return nil
+}
+
+// moveSizes moves the sizes tracked for name and its content
+// to newname, or forgets them if newname is empty. Files still
+// open keep their sizes, but share them with no new handle.
+// The caller must hold the lock.
+func (q *quotaFS) moveSizes(name string, newname string) {
+	for n, size := range q.sizes {
+		if n != name && name != "." && !strings.HasPrefix(n, name+"/") {
+			continue
+		}
+		delete(q.sizes, n)
+		if newname != "" {
+			rel := strings.TrimPrefix(strings.TrimPrefix(n, name), "/")
+			if name == "." {
+				rel = n
+			}
+			q.sizes[path.Join(newname, rel)] = size
+		}
+	}
+}
+
+// Rename implements RenameFS.
+// It returns an error wrapping errUnsupported
+// if the underlying fsys does not implement RenameFS.
+func (q *quotaFS) Rename(oldname, newname string) error {
+	rfs, ok := q.fsys.(RenameFS)
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: errUnsupported}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var bytes int64
+	var files int
+	if oldname != newname {
+		bytes, files = q.usage(newname)
+	}
+	if err := rfs.Rename(oldname, newname); err != nil {
+		return err
+	}
+	q.bytes -= bytes
+	q.files -= files
+	if oldname != newname {
+		q.moveSizes(newname, "")
+		q.moveSizes(oldname, newname)
+	}
+	return nil
+}
+
+// quotaFile is a file opened for writing
+// through a quotaFS.
+type quotaFile struct {
+	FileWriter
+	fsys   *quotaFS
+	name   string
+	append bool
+	offset int64
+	size   *quotaSize
+	closed bool
+}
+
+// Read implements fs.File
+func (f *quotaFile) Read(p []byte) (int, error) {
+	n, err := f.FileWriter.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// Write implements io.Writer
+func (f *quotaFile) Write(p []byte) (int, error) {
+	q := f.fsys
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if f.append {
+		f.offset = f.size.size
+	}
+	if !f.fits(f.offset + int64(len(p))) {
+		return 0, &fs.PathError{Op: "Write", Path: f.name, Err: ErrQuotaExceeded}
+	}
+
+	n, err := f.FileWriter.Write(p)
+	f.offset += int64(n)
+	f.resize(f.offset, false)
+	return n, err
+}
+
+// WriteAt implements io.WriterAt.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.WriterAt.
+func (f *quotaFile) WriteAt(p []byte, off int64) (int, error) {
+	w, ok := f.FileWriter.(io.WriterAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: &UnsupportedError{Interface: "WriterAtFile"}}
+	}
+
+	q := f.fsys
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !f.fits(off + int64(len(p))) {
+		return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: ErrQuotaExceeded}
+	}
+	n, err := w.WriteAt(p, off)
+	f.resize(off+int64(n), false)
+	return n, err
+}
+
+// Truncate implements TruncaterFile
+func (f *quotaFile) Truncate(size int64) error {
+	q := f.fsys
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !f.fits(size) {
+		return &fs.PathError{Op: "Truncate", Path: f.name, Err: ErrQuotaExceeded}
+	}
+	if err := truncateFile(f.FileWriter, f.name, size); err != nil {
+		return err
+	}
+	f.resize(size, true)
+	return nil
+}
+
+// Close implements fs.File
+func (f *quotaFile) Close() error {
+	q := f.fsys
+	q.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		f.size.refs--
+		if f.size.refs == 0 && q.sizes[f.name] == f.size {
+			delete(q.sizes, f.name)
+		}
+	}
+	q.mu.Unlock()
+	return f.FileWriter.Close()
+}
+
+// Sync implements SyncerFile
+func (f *quotaFile) Sync() error {
+	return syncFile(f.FileWriter, f.name)
+}
+
+// fits reports whether growing the file up
+// to size bytes respects the quota.
+// The caller must hold the lock.
+func (f *quotaFile) fits(size int64) bool {
+	q := f.fsys
+	growth := size - f.size.size
+	return growth <= 0 || q.maxBytes < 0 || q.bytes+growth <= q.maxBytes
+}
+
+// resize records that the file is now size bytes long.
+// Unless shrink is true, only growth is recorded.
+// The caller must hold the lock.
+func (f *quotaFile) resize(size int64, shrink bool) {
+	if size > f.size.size || shrink {
+		f.fsys.bytes += size - f.size.size
+		f.size.size = size
+	}
+}
+
+// Seek implements io.Seeker.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.Seeker.
+func (f *quotaFile) Seek(offset int64, whence int) (int64, error) {
+	pos, err := seekFile(f.FileWriter, f.name, offset, whence)
+	if err == nil {
+		f.offset = pos
+	}
+	return pos, err
+}
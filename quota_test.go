@@ -0,0 +1,153 @@
+package writefs_test
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQuota(t *testing.T) {
+	// newTreeFS contains 3 files of 4 bytes each.
+
+	t.Run("fails writes exceeding maxBytes", func(t *testing.T) {
+		mem := newTreeFS()
+		fsys := writefs.WithQuota(mem, 20, -1)
+
+		_, err := writefs.WriteFile(fsys, "file5", []byte("12345678"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(fsys, "file6", []byte("1"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+		var perr *fs.PathError
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, "file6", perr.Path)
+
+		// the file was created before the failing Write
+		data, err := fs.ReadFile(mem, "file6")
+		require.NoError(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("fails creations exceeding maxFiles", func(t *testing.T) {
+		fsys := writefs.WithQuota(newTreeFS(), -1, 4)
+		_, err := writefs.WriteFile(fsys, "file5", []byte("hello"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(fsys, "file6", []byte("hello"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+		_, err = fs.Stat(fsys, "file6")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = writefs.WriteFile(fsys, "file5", []byte("overwritten"))
+		assert.NoError(t, err)
+		assert.NoError(t, writefs.MkdirAll(fsys, "dir5/dir6", 0755))
+	})
+
+	t.Run("accounts for Truncate", func(t *testing.T) {
+		fsys := writefs.WithQuota(newTreeFS(), 12, -1)
+		_, err := writefs.AppendFile(fsys, "file1", []byte("1"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+
+		_, err = writefs.WriteFile(fsys, "file1", []byte("1234"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(fsys, "file1", []byte("12345"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+	})
+
+	t.Run("accounts for deletions", func(t *testing.T) {
+		fsys := writefs.WithQuota(newTreeFS(), 12, 3)
+		_, err := writefs.WriteFile(fsys, "file5", []byte("12345678"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+
+		require.NoError(t, writefs.RemoveAll(fsys, "dir1"))
+		_, err = writefs.WriteFile(fsys, "file5", []byte("12345678"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(fsys, "file6", []byte("1"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+	})
+
+	t.Run("accounts for files replaced by Rename", func(t *testing.T) {
+		fsys := writefs.WithQuota(newTreeFS(), 12, 3)
+		require.NoError(t, writefs.Rename(fsys, "file1", "dir1/file2"))
+		_, err := writefs.WriteFile(fsys, "file5", []byte("1234"))
+		require.NoError(t, err)
+	})
+
+	t.Run("accounts for WriteAt and TruncateFile", func(t *testing.T) {
+		fsys := writefs.WithQuota(newTreeFS(), 14, -1)
+		file, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly, 0)
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = writefs.WriteAt(file, []byte("12"), 4)
+		require.NoError(t, err)
+		_, err = writefs.WriteAt(file, []byte("1"), 6)
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+		assert.ErrorIs(t, writefs.TruncateFile(file, 7), writefs.ErrQuotaExceeded)
+
+		require.NoError(t, writefs.TruncateFile(file, 0))
+		require.NoError(t, writefs.TruncateFile(file, 6))
+		_, err = writefs.WriteFile(fsys, "file5", []byte("12"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+	})
+
+	t.Run("counts files opened twice once", func(t *testing.T) {
+		fsys := writefs.WithQuota(writefs.NewMemFS(nil), 15, -1)
+		a, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly|writefs.Create, 0644)
+		require.NoError(t, err)
+		defer a.Close()
+		b, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly, 0)
+		require.NoError(t, err)
+		defer b.Close()
+
+		_, err = a.Write([]byte("1234567890"))
+		require.NoError(t, err)
+		_, err = b.Write([]byte("abcdefghij"))
+		require.NoError(t, err)
+		_, err = b.Write([]byte("123456"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+		_, err = a.Write([]byte("12345"))
+		require.NoError(t, err)
+
+		data, err := fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "abcdefghij12345", string(data))
+	})
+
+	t.Run("forgets sizes of deleted files", func(t *testing.T) {
+		fsys := writefs.WithQuota(writefs.NewMemFS(nil), 15, -1)
+		a, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly|writefs.Create, 0644)
+		require.NoError(t, err)
+		defer a.Close()
+		_, err = a.Write([]byte("1234567890"))
+		require.NoError(t, err)
+		require.NoError(t, writefs.Remove(fsys, "file1"))
+
+		_, err = writefs.WriteFile(fsys, "file1", []byte("123456789012345"))
+		require.NoError(t, err)
+		_, err = writefs.AppendFile(fsys, "file1", []byte("1"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+	})
+
+	t.Run("tracks offset of ReadWrite files", func(t *testing.T) {
+		fsys := writefs.WithQuota(newTreeFS(), 14, -1)
+		file, err := writefs.OpenFile(fsys, "file1", writefs.ReadWrite, 0)
+		require.NoError(t, err)
+		buf := make([]byte, 2)
+		_, err = file.Read(buf)
+		require.NoError(t, err)
+		_, err = file.Write([]byte("1234"))
+		require.NoError(t, err)
+		_, err = file.(io.Seeker).Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		_, err = file.Write([]byte("1"))
+		assert.ErrorIs(t, err, writefs.ErrQuotaExceeded)
+		require.NoError(t, file.Close())
+
+		data, err := fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "ci1234", string(data))
+	})
+}
@@ -0,0 +1,228 @@
+package writefs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// errReadOnlyFS is returned by file systems
+// returned by ReadOnlyFS for every write operation.
+var errReadOnlyFS = fmt.Errorf("%w fsys: read-only file system", fs.ErrPermission)
+
+// ReadOnlyFS returns a view of fsys that still implements
+// WriteFS, but denies any modification.
+//
+// OpenFile accepts only ReadOnly opens, optionally with
+// Synchronous flag, and forwards them to fsys.
+// Any other flag, including the Truncate convention to delete
+// files and the Create convention to create directories, is
+// rejected with a *fs.PathError wrapping fs.ErrPermission.
+// Files returned by Open and OpenFile are wrapped, so that the
+// files of fsys are never exposed: their Write, WriteAt and
+// Truncate methods fail the same way.
+// The returned file system also implements RemoveFS, MkDirFS
+// and RenameFS, so that Remove, RemoveAll, MkDir, MkdirAll,
+// Rename and WriteFileAtomic fail the same way, and
+// fs.SubFS, so that its subtrees are read-only too.
+//
+// The function cannot be named ReadOnly, since that
+// name is used by the ReadOnly flag.
+func ReadOnlyFS(fsys WriteFS) WriteFS {
+	if fsys, ok := fsys.(*readOnlyFS); ok {
+		return fsys
+	}
+	return &readOnlyFS{fsys: fsys}
+}
+
+type readOnlyFS struct {
+	fsys WriteFS
+}
+
+var (
+	_ WriteFS        = &readOnlyFS{}
+	_ fs.StatFS      = &readOnlyFS{}
+	_ fs.ReadDirFS   = &readOnlyFS{}
+	_ fs.ReadFileFS  = &readOnlyFS{}
+	_ fs.GlobFS      = &readOnlyFS{}
+	_ fs.SubFS       = &readOnlyFS{}
+	_ RemoveFS       = &readOnlyFS{}
+	_ MkDirFS        = &readOnlyFS{}
+	_ RenameFS       = &readOnlyFS{}
+	_ CapabilitiesFS = &readOnlyFS{}
+	_ linkFS         = &readOnlyFS{}
+)
+
+// deny returns the error reported by write operations.
+func (f *readOnlyFS) deny(op string, name string) error {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return &fs.PathError{Op: op, Path: name, Err: errReadOnlyFS}
+}
+
+// Open implements fs.FS
+func (f *readOnlyFS) Open(name string) (fs.File, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyFile{file: file, name: name}, nil
+}
+
+// OpenFile implements WriteFS
+func (f *readOnlyFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	if flag&^Synchronous != ReadOnly {
+		return nil, f.deny("OpenFile", name)
+	}
+	file, err := f.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyFile{file: file, name: name}, nil
+}
+
+// Stat implements fs.StatFS
+func (f *readOnlyFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, name)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (f *readOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.fsys, name)
+}
+
+// ReadFile implements fs.ReadFileFS
+func (f *readOnlyFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, name)
+}
+
+// Glob implements fs.GlobFS
+func (f *readOnlyFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(f.fsys, pattern)
+}
+
+// Sub implements fs.SubFS
+func (f *readOnlyFS) Sub(dir string) (fs.FS, error) {
+	sub, err := Sub(f.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return ReadOnlyFS(sub), nil
+}
+
+// Capabilities implements CapabilitiesFS.
+// Only CapSeek is reported, if the underlying fsys supports it.
+func (f *readOnlyFS) Capabilities() Capability {
+	return Capabilities(f.fsys) & CapSeek
+}
+
+// Remove implements RemoveFS
+func (f *readOnlyFS) Remove(name string) error {
+	return f.deny("Remove", name)
+}
+
+// MkDir implements MkDirFS
+func (f *readOnlyFS) MkDir(name string, perm fs.FileMode) error {
+	return f.deny("MkDir", name)
+}
+
+// Rename implements RenameFS
+func (f *readOnlyFS) Rename(oldname, newname string) error {
+	return f.deny("Rename", oldname)
+}
+
+// Lstat returns a fs.FileInfo describing the named file of the
+// underlying fsys, without following it if it is a symbolic link.
+func (f *readOnlyFS) Lstat(name string) (fs.FileInfo, error) {
+	return Lstat(f.fsys, name)
+}
+
+// Readlink returns the target of the named
+// symbolic link of the underlying fsys.
+func (f *readOnlyFS) Readlink(name string) (string, error) {
+	return Readlink(f.fsys, name)
+}
+
+// readOnlyFile is a file opened through a readOnlyFS.
+// It forwards reads to the underlying file, and denies writes.
+type readOnlyFile struct {
+	file fs.File
+	name string
+}
+
+var (
+	_ FileWriter     = &readOnlyFile{}
+	_ fs.ReadDirFile = &readOnlyFile{}
+	_ io.Seeker      = &readOnlyFile{}
+	_ io.ReaderAt    = &readOnlyFile{}
+	_ WriterAtFile   = &readOnlyFile{}
+	_ TruncaterFile  = &readOnlyFile{}
+)
+
+// Stat implements fs.File
+func (f *readOnlyFile) Stat() (fs.FileInfo, error) {
+	return f.file.Stat()
+}
+
+// Read implements io.Reader
+func (f *readOnlyFile) Read(p []byte) (int, error) {
+	return f.file.Read(p)
+}
+
+// Close implements fs.File
+func (f *readOnlyFile) Close() error {
+	return f.file.Close()
+}
+
+// ReadDir implements fs.ReadDirFile.
+// It returns an error wrapping ErrNotDir
+// if the underlying file is not a directory.
+func (f *readOnlyFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	d, ok := f.file.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "ReadDir", Path: f.name, Err: ErrNotDir}
+	}
+	return d.ReadDir(n)
+}
+
+// Seek implements io.Seeker.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.Seeker.
+func (f *readOnlyFile) Seek(offset int64, whence int) (int64, error) {
+	s, ok := f.file.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "Seek", Path: f.name, Err: &UnsupportedError{Interface: "io.Seeker"}}
+	}
+	return s.Seek(offset, whence)
+}
+
+// ReadAt implements io.ReaderAt.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.ReaderAt.
+func (f *readOnlyFile) ReadAt(p []byte, off int64) (int, error) {
+	r, ok := f.file.(io.ReaderAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "ReadAt", Path: f.name, Err: &UnsupportedError{Interface: "io.ReaderAt"}}
+	}
+	return r.ReadAt(p, off)
+}
+
+// Write implements io.Writer, always failing
+// with an error wrapping fs.ErrPermission.
+func (f *readOnlyFile) Write(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "Write", Path: f.name, Err: errReadOnlyFS}
+}
+
+// WriteAt implements io.WriterAt, always failing
+// with an error wrapping fs.ErrPermission.
+func (f *readOnlyFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: errReadOnlyFS}
+}
+
+// Truncate implements TruncaterFile, always failing
+// with an error wrapping fs.ErrPermission.
+func (f *readOnlyFile) Truncate(size int64) error {
+	return &fs.PathError{Op: "Truncate", Path: f.name, Err: errReadOnlyFS}
+}
@@ -0,0 +1,123 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyFS(t *testing.T) {
+	t.Run("allows ReadOnly opens", func(t *testing.T) {
+		fsys := writefs.ReadOnlyFS(newTreeFS())
+		file, err := writefs.OpenFile(fsys, "file1", writefs.ReadOnly, 0)
+		require.NoError(t, err)
+		buf := make([]byte, 4)
+		_, err = file.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(buf))
+		assert.NoError(t, file.Close())
+	})
+
+	t.Run("passes fstest.TestFS", func(t *testing.T) {
+		fsys := writefs.ReadOnlyFS(newTreeFS())
+		assert.NoError(t, fstest.TestFS(fsys, "file1", "dir1/file2", "dir1/dir2/file3"))
+	})
+
+	for name, fn := range map[string]func(fsys writefs.WriteFS) error{
+		"WriteFile": func(fsys writefs.WriteFS) error {
+			_, err := writefs.WriteFile(fsys, "file1", []byte("hello"))
+			return err
+		},
+		"create": func(fsys writefs.WriteFS) error {
+			_, err := writefs.OpenFile(fsys, "file5", writefs.ReadOnly|writefs.Create, 0644)
+			return err
+		},
+		"Truncate convention": func(fsys writefs.WriteFS) error {
+			_, err := writefs.OpenFile(fsys, "file1", writefs.Truncate, 0)
+			return err
+		},
+		"Create convention": func(fsys writefs.WriteFS) error {
+			_, err := writefs.OpenFile(fsys, "dir5", writefs.Create, fs.ModeDir|0755)
+			return err
+		},
+		"Remove": func(fsys writefs.WriteFS) error {
+			return writefs.Remove(fsys, "file1")
+		},
+		"RemoveAll": func(fsys writefs.WriteFS) error {
+			return writefs.RemoveAll(fsys, "dir1")
+		},
+		"MkdirAll": func(fsys writefs.WriteFS) error {
+			return writefs.MkdirAll(fsys, "dir5/dir6", 0755)
+		},
+		"Rename": func(fsys writefs.WriteFS) error {
+			return writefs.Rename(fsys, "file1", "file5")
+		},
+		"WriteFileAtomic": func(fsys writefs.WriteFS) error {
+			_, err := writefs.WriteFileAtomic(fsys, "file1", []byte("hello"), 0644)
+			return err
+		},
+		"Sub": func(fsys writefs.WriteFS) error {
+			sub, err := writefs.Sub(fsys, "dir1")
+			require.NoError(t, err)
+			_, err = writefs.WriteFile(sub, "file2", []byte("hello"))
+			return err
+		},
+	} {
+		t.Run("denies "+name, func(t *testing.T) {
+			mem := newTreeFS()
+			before := mem.MapFS()
+
+			err := fn(writefs.ReadOnlyFS(mem))
+			assert.ErrorIs(t, err, fs.ErrPermission)
+			var perr *fs.PathError
+			assert.ErrorAs(t, err, &perr)
+			assert.Equal(t, before, mem.MapFS())
+		})
+	}
+
+	t.Run("denies writes through opened files", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, "file1"), []byte("ciao"), 0644))
+		fsys := writefs.ReadOnlyFS(writefs.DirFS(root))
+
+		file, err := writefs.OpenFile(fsys, "file1", writefs.ReadOnly, 0)
+		require.NoError(t, err)
+		defer file.Close()
+		_, ok := file.(*os.File)
+		assert.False(t, ok)
+
+		_, err = file.Write([]byte("hello"))
+		assert.True(t, errors.Is(err, fs.ErrPermission))
+		assert.EqualError(t, err, "Write file1: permission denied fsys: read-only file system")
+		_, err = writefs.WriteAt(file, []byte("hello"), 0)
+		assert.ErrorIs(t, err, fs.ErrPermission)
+		assert.ErrorIs(t, writefs.TruncateFile(file, 0), fs.ErrPermission)
+
+		opened, err := fsys.Open("file1")
+		require.NoError(t, err)
+		defer opened.Close()
+		_, ok = opened.(*os.File)
+		assert.False(t, ok)
+
+		data, err := os.ReadFile(filepath.Join(root, "file1"))
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+	})
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		err := writefs.ReadOnlyFS(newTreeFS()).(writefs.RemoveFS).Remove("/file1")
+		assert.Equal(t, "Remove /file1: invalid argument name: not a valid path", err.Error())
+	})
+
+	t.Run("does not wrap twice", func(t *testing.T) {
+		fsys := writefs.ReadOnlyFS(newTreeFS())
+		assert.Same(t, fsys, writefs.ReadOnlyFS(fsys))
+	})
+}
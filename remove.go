@@ -0,0 +1,160 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// RemoveFS is the interface implemented by a file system
+// that provides an optimized implementation of Remove.
+type RemoveFS interface {
+	fs.FS
+
+	// Remove removes the named file or empty directory.
+	// If there is an error, it will be of type *fs.PathError.
+	Remove(name string) error
+}
+
+// Remove removes the named file or empty directory.
+//
+// If fsys implements RemoveFS, Remove calls fsys.Remove.
+// Otherwise, if fsys implements WriteFS, Remove checks that the
+// directory is empty and then deletes name by calling
+// OpenFile with Truncate flag only.
+//
+// If there is an error, it will be of type *fs.PathError.
+func Remove(fsys fs.FS, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "Remove", Path: name, Err: err}
+	}
+
+	if fsys, ok := fsys.(RemoveFS); ok {
+		if err := fsys.Remove(name); err != nil {
+			return opPathError("Remove", name, err)
+		}
+		return nil
+	}
+
+	typ, err := entryType(fsys, name)
+	if err != nil {
+		return opPathError("Remove", name, err)
+	}
+	if typ.IsDir() {
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return opPathError("Remove", name, err)
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "Remove", Path: name, Err: ErrNotEmpty}
+		}
+	}
+
+	if _, err := OpenFile(fsys, name, Truncate, 0); err != nil {
+		return opPathError("Remove", name, err)
+	}
+	return nil
+}
+
+// RemoveAll removes name and any children it contains.
+// It removes everything it can but returns the first error
+// it encounters. If name does not exist,
+// RemoveAll returns nil (no error).
+//
+// If fsys implements RemoveFS, RemoveAll walks name
+// removing every entry with fsys.Remove.
+// Otherwise, if fsys implements WriteFS, RemoveAll deletes
+// name by calling OpenFile with Truncate flag only.
+//
+// If there is an error, it will be of type *fs.PathError.
+func RemoveAll(fsys fs.FS, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "RemoveAll", Path: name, Err: err}
+	}
+
+	var err error
+	if rfs, ok := fsys.(RemoveFS); ok {
+		err = removeAll(rfs, name)
+	} else {
+		_, err = OpenFile(fsys, name, Truncate, 0)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return opPathError("RemoveAll", name, err)
+	}
+	return nil
+}
+
+// removeAll recursively removes name using fsys.Remove.
+// Symbolic links are removed, never followed.
+func removeAll(fsys RemoveFS, name string) error {
+	typ, err := entryType(fsys, name)
+	if err != nil {
+		return err
+	}
+	return removeTree(fsys, name, typ)
+}
+
+// removeTree removes name, whose type is typ,
+// and all its content.
+func removeTree(fsys RemoveFS, name string, typ fs.FileMode) error {
+	var firstErr error
+	if typ.IsDir() {
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err := removeTree(fsys, path.Join(name, entry.Name()), entry.Type())
+			if err != nil && !errors.Is(err, fs.ErrNotExist) && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if err := fsys.Remove(name); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// entryType returns the type bits of name, without
+// following it if it is a symbolic link.
+// The type is read from the entry of name in its
+// parent directory.
+func entryType(fsys fs.FS, name string) (fs.FileMode, error) {
+	entry, err := dirEntry(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	return entry.Type(), nil
+}
+
+// dirEntry returns the fs.DirEntry for name,
+// read from its parent directory.
+func dirEntry(fsys fs.FS, name string) (fs.DirEntry, error) {
+	entries, err := fs.ReadDir(fsys, path.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	base := path.Base(name)
+	for _, entry := range entries {
+		if entry.Name() == base {
+			return entry, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "Stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// opPathError converts err to a *fs.PathError
+// reporting operation op. If err already wraps
+// a *fs.PathError, its path is preserved.
+func opPathError(op string, name string, err error) error {
+	var perr *fs.PathError
+	if errors.As(err, &perr) {
+		return &fs.PathError{Op: op, Path: perr.Path, Err: perr.Err}
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
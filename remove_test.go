@@ -0,0 +1,114 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	mockfs "github.com/parrogo/writefs/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openFileOnlyFS hides any method of the
+// embedded WriteFS but Open and OpenFile.
+type openFileOnlyFS struct {
+	writefs.WriteFS
+}
+
+func newTreeFS() *writefs.MemFS {
+	return writefs.NewMemFS(fstest.MapFS{
+		"dir1/dir2/file3": &fstest.MapFile{Data: []byte("ciao")},
+		"dir1/file2":      &fstest.MapFile{Data: []byte("ciao")},
+		"dir4":            &fstest.MapFile{Mode: fs.ModeDir | 0755},
+		"file1":           &fstest.MapFile{Data: []byte("ciao")},
+	})
+}
+
+func TestRemove(t *testing.T) {
+	t.Run("calls fsys.Remove when fsys implements RemoveFS", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		testfs.On("Remove", "dir1/file2").Return(nil)
+
+		assert.NoError(t, writefs.Remove(&testfs, "dir1/file2"))
+		testfs.AssertExpectations(t)
+	})
+
+	t.Run("wraps fsys.Remove errors", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		testfs.On("Remove", "dir1/file2").Return(errors.New("expected"))
+
+		err := writefs.Remove(&testfs, "dir1/file2")
+		assert.Equal(t, "Remove dir1/file2: expected", err.Error())
+		testfs.AssertExpectations(t)
+	})
+
+	for name, fsys := range map[string]func() writefs.WriteFS{
+		"RemoveFS": func() writefs.WriteFS { return newTreeFS() },
+		"fallback": func() writefs.WriteFS { return openFileOnlyFS{newTreeFS()} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("removes files", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.Remove(fsys, "file1"))
+				_, err := fs.Stat(fsys, "file1")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+			})
+
+			t.Run("removes empty directories", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.Remove(fsys, "dir4"))
+				_, err := fs.Stat(fsys, "dir4")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+			})
+
+			t.Run("refuses non empty directories", func(t *testing.T) {
+				fsys := fsys()
+				err := writefs.Remove(fsys, "dir1")
+				assert.ErrorIs(t, err, fs.ErrExist)
+				var perr *fs.PathError
+				require.ErrorAs(t, err, &perr)
+				assert.Equal(t, "Remove", perr.Op)
+				assert.Equal(t, "dir1", perr.Path)
+			})
+
+			t.Run("returns ErrNotExist for missing files", func(t *testing.T) {
+				err := writefs.Remove(fsys(), "notexists")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+			})
+
+			t.Run("removes trees", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.RemoveAll(fsys, "dir1"))
+				_, err := fs.Stat(fsys, "dir1")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+				_, err = fs.Stat(fsys, "file1")
+				assert.NoError(t, err)
+			})
+
+			t.Run("RemoveAll ignores missing files", func(t *testing.T) {
+				assert.NoError(t, writefs.RemoveAll(fsys(), "notexists"))
+			})
+		})
+	}
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		err := writefs.Remove(newTreeFS(), "/")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+		assert.Equal(t, "Remove /: invalid argument name: not a valid path", err.Error())
+
+		err = writefs.RemoveAll(newTreeFS(), ".")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+		assert.Equal(t, "RemoveAll .: invalid argument name: not a valid path", err.Error())
+	})
+
+	t.Run("return PathError for read only fsys", func(t *testing.T) {
+		err := writefs.Remove(fixtureFS, "dir1/file2")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+		var perr *fs.PathError
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, "Remove", perr.Op)
+	})
+}
@@ -0,0 +1,125 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// RemoveOptions customizes RemoveTree behavior.
+type RemoveOptions struct {
+	// Keep, when not nil, is called for every entry
+	// before removing it. Entries for which it returns
+	// true are left untouched, along with all their content
+	// and the directories containing them.
+	Keep func(name string, d fs.DirEntry) bool
+	// DryRun, when true, makes RemoveTree report the
+	// entries that would be removed, without removing them.
+	DryRun bool
+	// OnRemove, when not nil, is called after every
+	// attempt to remove an entry, with the error
+	// occurred, if any. In DryRun mode it is called
+	// for every entry that would be removed.
+	OnRemove func(name string, err error)
+}
+
+// RemoveTree removes dir and any children it contains,
+// following opts, and returns the names of the removed
+// entries, children before their parent directory.
+// When opts.DryRun is true, nothing is removed and the
+// names of the entries that would be removed are returned.
+// If dir is ".", its content is removed, but not dir itself.
+// If dir does not exist, RemoveTree returns nil (no error).
+//
+// Entries are removed one at a time using Remove, and
+// symbolic links are removed, never followed.
+// RemoveTree removes everything it can: if errors occur
+// on single entries, it goes on and finally returns
+// a *TreeError that records all of them.
+// Otherwise, if there is an error, it will be of type *fs.PathError.
+func RemoveTree(fsys fs.FS, dir string, opts RemoveOptions) ([]string, error) {
+	if !fs.ValidPath(dir) {
+		err := fmt.Errorf("%w dir: not a valid path", fs.ErrInvalid)
+		return nil, &fs.PathError{Op: "RemoveTree", Path: dir, Err: err}
+	}
+	if _, ok := fsys.(RemoveFS); !ok && !writable(fsys) && !opts.DryRun {
+		return nil, &fs.PathError{Op: "RemoveTree", Path: dir, Err: ErrNotWritable}
+	}
+
+	r := treeRemover{fsys: fsys, opts: opts}
+	if dir == "." {
+		r.removeContent(dir)
+	} else {
+		entry, err := dirEntry(fsys, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, opPathError("RemoveTree", dir, err)
+		}
+		r.remove(dir, entry)
+	}
+
+	if len(r.errs) > 0 {
+		return r.removed, &TreeError{Op: "RemoveTree", Path: dir, Errs: r.errs}
+	}
+	return r.removed, nil
+}
+
+// treeRemover holds the state of a RemoveTree call.
+type treeRemover struct {
+	fsys    fs.FS
+	opts    RemoveOptions
+	removed []string
+	errs    []error
+}
+
+// remove removes name, described by d, and all its content.
+// It reports whether name has been removed.
+func (r *treeRemover) remove(name string, d fs.DirEntry) bool {
+	if r.opts.Keep != nil && r.opts.Keep(name, d) {
+		return false
+	}
+	if d.IsDir() && !r.removeContent(name) {
+		return false
+	}
+
+	var err error
+	if !r.opts.DryRun {
+		err = Remove(r.fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// already removed by someone else
+			return true
+		}
+		if err != nil {
+			err = opPathError("RemoveTree", name, err)
+			r.errs = append(r.errs, err)
+		}
+	}
+	if r.opts.OnRemove != nil {
+		r.opts.OnRemove(name, err)
+	}
+	if err != nil {
+		return false
+	}
+	r.removed = append(r.removed, name)
+	return true
+}
+
+// removeContent removes all the entries of directory dir.
+// It reports whether all of them have been removed.
+func (r *treeRemover) removeContent(dir string) bool {
+	entries, err := fs.ReadDir(r.fsys, dir)
+	if err != nil {
+		r.errs = append(r.errs, opPathError("RemoveTree", dir, err))
+		return false
+	}
+	all := true
+	for _, entry := range entries {
+		if !r.remove(path.Join(dir, entry.Name()), entry) {
+			all = false
+		}
+	}
+	return all
+}
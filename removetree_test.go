@@ -0,0 +1,111 @@
+package writefs_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveTree(t *testing.T) {
+	t.Run("removes all entries", func(t *testing.T) {
+		fsys := newTreeFS()
+		removed, err := writefs.RemoveTree(fsys, "dir1", writefs.RemoveOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dir1/dir2/file3", "dir1/dir2", "dir1/file2", "dir1"}, removed)
+		assert.ElementsMatch(t, []string{"dir4", "file1"}, mapKeys(fsys.MapFS()))
+	})
+
+	t.Run("removes content of root", func(t *testing.T) {
+		fsys := newTreeDirFS(t)
+		removed, err := writefs.RemoveTree(fsys, ".", writefs.RemoveOptions{})
+		require.NoError(t, err)
+		assert.Len(t, removed, 6)
+		assert.Empty(t, entryNames(t, fsys, "."))
+	})
+
+	t.Run("keeps entries", func(t *testing.T) {
+		fsys := newTreeFS()
+		removed, err := writefs.RemoveTree(fsys, ".", writefs.RemoveOptions{
+			Keep: func(name string, d fs.DirEntry) bool {
+				return name == "dir1/dir2" || d.Name() == "dir4"
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dir1/file2", "file1"}, removed)
+		assert.ElementsMatch(t, []string{"dir1", "dir1/dir2", "dir1/dir2/file3", "dir4"}, mapKeys(fsys.MapFS()))
+	})
+
+	t.Run("does not remove anything in dry run", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"dir1/file2": &fstest.MapFile{},
+			"file1":      &fstest.MapFile{},
+		}
+		var log []string
+		removed, err := writefs.RemoveTree(fsys, ".", writefs.RemoveOptions{
+			DryRun:   true,
+			OnRemove: func(name string, err error) { log = append(log, name) },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dir1/file2", "dir1", "file1"}, removed)
+		assert.Equal(t, removed, log)
+		assert.Len(t, fsys, 2)
+	})
+
+	t.Run("goes on after errors", func(t *testing.T) {
+		vetoed := errors.New("vetoed")
+		mem := newTreeFS()
+		fsys := writefs.WithHooks(mem, writefs.Hooks{
+			OnRemove: func(name string) error {
+				if name == "dir1/file2" || name == "dir4" {
+					return vetoed
+				}
+				return nil
+			},
+		})
+		var log []string
+		removed, err := writefs.RemoveTree(fsys, ".", writefs.RemoveOptions{
+			OnRemove: func(name string, err error) { log = append(log, fmt.Sprintf("%s %v", name, err)) },
+		})
+
+		assert.Equal(t, []string{"dir1/dir2/file3", "dir1/dir2", "file1"}, removed)
+		assert.Equal(t, []string{
+			"dir1/dir2/file3 <nil>",
+			"dir1/dir2 <nil>",
+			"dir1/file2 RemoveTree dir1/file2: vetoed",
+			"dir4 RemoveTree dir4: vetoed",
+			"file1 <nil>",
+		}, log)
+		assert.ErrorIs(t, err, vetoed)
+		var terr *writefs.TreeError
+		require.ErrorAs(t, err, &terr)
+		assert.Len(t, terr.Errs, 2)
+		assert.ElementsMatch(t, []string{"dir1", "dir1/file2", "dir4"}, mapKeys(mem.MapFS()))
+	})
+
+	t.Run("does not follow symbolic links", func(t *testing.T) {
+		fsys := newTreeDirFS(t)
+		require.NoError(t, writefs.Symlink(fsys, "../dir4", "dir1/link"))
+		_, err := writefs.RemoveTree(fsys, "dir1", writefs.RemoveOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dir4", "file1"}, entryNames(t, fsys, "."))
+	})
+
+	t.Run("ignores not existent dir", func(t *testing.T) {
+		removed, err := writefs.RemoveTree(newTreeFS(), "not-existent", writefs.RemoveOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, removed)
+	})
+
+	t.Run("return PathError on read-only file systems", func(t *testing.T) {
+		_, err := writefs.RemoveTree(fstest.MapFS{}, ".", writefs.RemoveOptions{})
+		assert.ErrorIs(t, err, writefs.ErrNotWritable)
+		var perr *fs.PathError
+		assert.ErrorAs(t, err, &perr)
+	})
+}
@@ -0,0 +1,113 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// RenameFS is the interface implemented by a file system
+// that supports renaming files and directories.
+type RenameFS interface {
+	fs.FS
+
+	// Rename renames (moves) oldname to newname.
+	// If newname already exists and is not a directory,
+	// Rename replaces it.
+	// Implementations should perform the operation atomically.
+	// If there is an error, it will be of type *fs.PathError.
+	Rename(oldname, newname string) error
+}
+
+// Rename renames (moves) oldname to newname.
+// If newname already exists and is not a directory,
+// Rename replaces it.
+//
+// If fsys implements RenameFS, Rename calls fsys.Rename, and
+// the operation is atomic if the implementation is.
+//
+// Otherwise, or if fsys is a wrapper returned by this package
+// whose underlying file system does not implement RenameFS,
+// or if fsys.Rename returns an error wrapping ErrCrossDevice,
+// if fsys implements WriteFS, Rename copies oldname
+// content to newname using OpenFile, and then deletes oldname
+// by calling OpenFile with Truncate flag only. This fallback is
+// not atomic: if it fails, newname could be partially written
+// and oldname could still exist. It only supports regular files.
+//
+// If there is an error, it will be of type *fs.PathError.
+func Rename(fsys fs.FS, oldname, newname string) error {
+	for _, name := range []string{oldname, newname} {
+		if !fs.ValidPath(name) || name == "." {
+			err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+			return &fs.PathError{Op: "Rename", Path: name, Err: err}
+		}
+	}
+
+	if fsys, ok := fsys.(RenameFS); ok {
+		err := fsys.Rename(oldname, newname)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errUnsupported) && !errors.Is(err, ErrCrossDevice) {
+			return opPathError("Rename", oldname, err)
+		}
+	}
+
+	if oldname == newname {
+		return nil
+	}
+	if err := copyRename(fsys, oldname, newname); err != nil {
+		return opPathError("Rename", oldname, err)
+	}
+	return nil
+}
+
+// copyRename renames oldname to newname
+// by copying its content and deleting it.
+func copyRename(fsys fs.FS, oldname, newname string) error {
+	typ, err := entryType(fsys, oldname)
+	if err != nil {
+		return err
+	}
+	if !typ.IsRegular() {
+		err := fmt.Errorf("%w oldname: not a regular file and fsys does not implement RenameFS", fs.ErrInvalid)
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: err}
+	}
+
+	src, err := fsys.Open(oldname)
+	if err != nil {
+		return err
+	}
+	err = copyFileContent(fsys, newname, src)
+	if errClose := src.Close(); errClose != nil && err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = OpenFile(fsys, oldname, Truncate, 0)
+	return err
+}
+
+// copyFileContent copies content of src to newname,
+// creating it with the same permissions of src
+// if it does not exist.
+func copyFileContent(fsys fs.FS, newname string, src fs.File) error {
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := OpenFile(fsys, newname, WriteOnly|Create|Truncate, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return &fs.PathError{Op: "Rename", Path: newname, Err: err}
+	}
+	return dst.Close()
+}
@@ -0,0 +1,107 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	mockfs "github.com/parrogo/writefs/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	t.Run("calls fsys.Rename when fsys implements RenameFS", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		testfs.On("Rename", "file1", "file2").Return(nil)
+
+		assert.NoError(t, writefs.Rename(&testfs, "file1", "file2"))
+		testfs.AssertExpectations(t)
+	})
+
+	t.Run("wraps fsys.Rename errors", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		testfs.On("Rename", "file1", "file2").Return(errors.New("expected"))
+
+		err := writefs.Rename(&testfs, "file1", "file2")
+		assert.Equal(t, "Rename file1: expected", err.Error())
+		testfs.AssertExpectations(t)
+	})
+
+	for name, fsys := range map[string]func() writefs.WriteFS{
+		"MemFS":    func() writefs.WriteFS { return newTreeFS() },
+		"DirFS":    func() writefs.WriteFS { return newTreeDirFS(t) },
+		"fallback": func() writefs.WriteFS { return openFileOnlyFS{newTreeFS()} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("renames files", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.Rename(fsys, "file1", "dir4/file5"))
+				_, err := fs.Stat(fsys, "file1")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+				data, err := fs.ReadFile(fsys, "dir4/file5")
+				require.NoError(t, err)
+				assert.Equal(t, "ciao", string(data))
+			})
+
+			t.Run("replaces existing files", func(t *testing.T) {
+				fsys := fsys()
+				_, err := writefs.WriteFile(fsys, "file1", []byte("replaced"))
+				require.NoError(t, err)
+				require.NoError(t, writefs.Rename(fsys, "file1", "dir1/file2"))
+				data, err := fs.ReadFile(fsys, "dir1/file2")
+				require.NoError(t, err)
+				assert.Equal(t, "replaced", string(data))
+			})
+
+			t.Run("returns ErrNotExist for missing files", func(t *testing.T) {
+				err := writefs.Rename(fsys(), "notexists", "file5")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+				var perr *fs.PathError
+				require.ErrorAs(t, err, &perr)
+				assert.Equal(t, "Rename", perr.Op)
+			})
+		})
+	}
+
+	for name, fsys := range map[string]func() writefs.WriteFS{
+		"MemFS": func() writefs.WriteFS { return newTreeFS() },
+		"DirFS": func() writefs.WriteFS { return newTreeDirFS(t) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("renames directories", func(t *testing.T) {
+				fsys := fsys()
+				require.NoError(t, writefs.Rename(fsys, "dir1", "dir5"))
+				data, err := fs.ReadFile(fsys, "dir5/dir2/file3")
+				require.NoError(t, err)
+				assert.Equal(t, "ciao", string(data))
+				_, err = fs.Stat(fsys, "dir1")
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+			})
+		})
+	}
+
+	t.Run("fallback refuses directories", func(t *testing.T) {
+		err := writefs.Rename(openFileOnlyFS{newTreeFS()}, "dir1", "dir5")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+	})
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		err := writefs.Rename(newTreeFS(), "file1", "/")
+		assert.Equal(t, "Rename /: invalid argument name: not a valid path", err.Error())
+	})
+}
+
+// newTreeDirFS returns a DirFS with
+// the same content of newTreeFS.
+func newTreeDirFS(t *testing.T) writefs.WriteFS {
+	fsys := writefs.DirFS(t.TempDir())
+	require.NoError(t, writefs.MkdirAll(fsys, "dir1/dir2", 0755))
+	require.NoError(t, writefs.MkDir(fsys, "dir4", 0755))
+	for _, name := range []string{"dir1/dir2/file3", "dir1/file2", "file1"} {
+		_, err := writefs.WriteFile(fsys, name, []byte("ciao"))
+		require.NoError(t, err)
+	}
+	return fsys
+}
@@ -0,0 +1,313 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// Sub returns a WriteFS corresponding to the subtree rooted at fsys's dir.
+// It is the writable counterpart of fs.Sub.
+//
+// If dir is ".", Sub returns fsys unchanged.
+// Otherwise, if fsys implements fs.SubFS and its Sub method
+// returns a WriteFS, Sub returns that.
+// Otherwise, Sub returns a new WriteFS implementation that
+// prefixes every name with dir, both in OpenFile and in the
+// optional interfaces fs.StatFS, fs.ReadDirFS, fs.ReadFileFS,
+// fs.GlobFS, fs.SubFS, RemoveFS, MkDirFS, RenameFS,
+// ChmodFS, ChownFS, ChtimesFS, SymlinkFS and LinkFS.
+// Paths of *fs.PathError returned are rewritten
+// to be relative to dir.
+func Sub(fsys WriteFS, dir string) (WriteFS, error) {
+	if !fs.ValidPath(dir) {
+		err := fmt.Errorf("%w dir: not a valid path", fs.ErrInvalid)
+		return nil, &fs.PathError{Op: "Sub", Path: dir, Err: err}
+	}
+	if dir == "." {
+		return fsys, nil
+	}
+	if fsys, ok := fsys.(fs.SubFS); ok {
+		sub, err := fsys.Sub(dir)
+		if err != nil {
+			return nil, err
+		}
+		if sub, ok := sub.(WriteFS); ok {
+			return sub, nil
+		}
+	}
+	return &subFS{fsys: fsys, dir: dir}, nil
+}
+
+type subFS struct {
+	fsys WriteFS
+	dir  string
+}
+
+var (
+	_ WriteFS        = &subFS{}
+	_ fs.StatFS      = &subFS{}
+	_ fs.ReadDirFS   = &subFS{}
+	_ fs.ReadFileFS  = &subFS{}
+	_ fs.GlobFS      = &subFS{}
+	_ fs.SubFS       = &subFS{}
+	_ RemoveFS       = &subFS{}
+	_ MkDirFS        = &subFS{}
+	_ RenameFS       = &subFS{}
+	_ CapabilitiesFS = &subFS{}
+	_ ChmodFS        = &subFS{}
+	_ ChownFS        = &subFS{}
+	_ ChtimesFS      = &subFS{}
+	_ SymlinkFS      = &subFS{}
+	_ LinkFS         = &subFS{}
+)
+
+// fullName maps name to the name used in the underlying fsys.
+func (f *subFS) fullName(op string, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(f.dir, name), nil
+}
+
+// shorten maps name, from the underlying fsys, to a name
+// relative to f.dir. ok is false if name is outside f.dir.
+func (f *subFS) shorten(name string) (rel string, ok bool) {
+	if name == f.dir {
+		return ".", true
+	}
+	if strings.HasPrefix(name, f.dir+"/") {
+		return name[len(f.dir)+1:], true
+	}
+	return "", false
+}
+
+// fixErr rewrites the path of *fs.PathError
+// to be relative to f.dir.
+func (f *subFS) fixErr(err error) error {
+	var perr *fs.PathError
+	if errors.As(err, &perr) {
+		if short, ok := f.shorten(perr.Path); ok {
+			return &fs.PathError{Op: perr.Op, Path: short, Err: perr.Err}
+		}
+	}
+	return err
+}
+
+// Open implements fs.FS
+func (f *subFS) Open(name string) (fs.File, error) {
+	full, err := f.fullName("Open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.Open(full)
+	return file, f.fixErr(err)
+}
+
+// OpenFile implements WriteFS
+func (f *subFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	full, err := f.fullName("OpenFile", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.OpenFile(full, flag, perm)
+	return file, f.fixErr(err)
+}
+
+// Stat implements fs.StatFS
+func (f *subFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := f.fullName("Stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(f.fsys, full)
+	return info, f.fixErr(err)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (f *subFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := f.fullName("ReadDir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.fsys, full)
+	return entries, f.fixErr(err)
+}
+
+// ReadFile implements fs.ReadFileFS
+func (f *subFS) ReadFile(name string) ([]byte, error) {
+	full, err := f.fullName("ReadFile", name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(f.fsys, full)
+	return data, f.fixErr(err)
+}
+
+// Glob implements fs.GlobFS
+func (f *subFS) Glob(pattern string) ([]string, error) {
+	// Check pattern is well-formed.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if pattern == "." {
+		return []string{"."}, nil
+	}
+
+	full := f.dir + "/" + pattern
+	list, err := fs.Glob(f.fsys, full)
+	for i, name := range list {
+		name, ok := f.shorten(name)
+		if !ok {
+			return nil, errors.New("invalid result from inner fsys Glob: " + name + " not in " + f.dir)
+		}
+		list[i] = name
+	}
+	return list, f.fixErr(err)
+}
+
+// Sub implements fs.SubFS
+func (f *subFS) Sub(dir string) (fs.FS, error) {
+	if dir == "." {
+		return f, nil
+	}
+	full, err := f.fullName("Sub", dir)
+	if err != nil {
+		return nil, err
+	}
+	return &subFS{fsys: f.fsys, dir: full}, nil
+}
+
+// Capabilities implements CapabilitiesFS
+func (f *subFS) Capabilities() Capability {
+	return Capabilities(f.fsys)
+}
+
+// Remove implements RemoveFS
+func (f *subFS) Remove(name string) error {
+	full, err := f.fullName("Remove", name)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return &fs.PathError{Op: "Remove", Path: name, Err: fs.ErrInvalid}
+	}
+	return f.fixErr(Remove(f.fsys, full))
+}
+
+// MkDir implements MkDirFS
+func (f *subFS) MkDir(name string, perm fs.FileMode) error {
+	full, err := f.fullName("MkDir", name)
+	if err != nil {
+		return err
+	}
+	return f.fixErr(MkDir(f.fsys, full, perm))
+}
+
+// Rename implements RenameFS.
+// It returns an error wrapping errUnsupported
+// if the underlying fsys does not implement RenameFS.
+func (f *subFS) Rename(oldname, newname string) error {
+	oldFull, err := f.fullName("Rename", oldname)
+	if err != nil {
+		return err
+	}
+	newFull, err := f.fullName("Rename", newname)
+	if err != nil {
+		return err
+	}
+	rfs, ok := f.fsys.(RenameFS)
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: errUnsupported}
+	}
+	return f.fixErr(rfs.Rename(oldFull, newFull))
+}
+
+// Chmod implements ChmodFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement ChmodFS.
+func (f *subFS) Chmod(name string, mode fs.FileMode) error {
+	full, err := f.fullName("Chmod", name)
+	if err != nil {
+		return err
+	}
+	return f.fixErr(Chmod(f.fsys, full, mode))
+}
+
+// Chown implements ChownFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement ChownFS.
+func (f *subFS) Chown(name string, uid, gid int) error {
+	full, err := f.fullName("Chown", name)
+	if err != nil {
+		return err
+	}
+	return f.fixErr(Chown(f.fsys, full, uid, gid))
+}
+
+// Chtimes implements ChtimesFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement ChtimesFS.
+func (f *subFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	full, err := f.fullName("Chtimes", name)
+	if err != nil {
+		return err
+	}
+	return f.fixErr(Chtimes(f.fsys, full, atime, mtime))
+}
+
+// Symlink implements SymlinkFS.
+// Targets pointing outside of the subtree are refused
+// with a *fs.PathError wrapping fs.ErrInvalid.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement SymlinkFS.
+func (f *subFS) Symlink(oldname, newname string) error {
+	full, err := f.fullName("Symlink", newname)
+	if err != nil {
+		return err
+	}
+	if err := checkLinkTarget(f.fsys, f.dir, oldname, newname); err != nil {
+		return err
+	}
+	return f.fixErr(Symlink(f.fsys, oldname, full))
+}
+
+// Readlink implements SymlinkFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not support symbolic links.
+func (f *subFS) Readlink(name string) (string, error) {
+	full, err := f.fullName("Readlink", name)
+	if err != nil {
+		return "", err
+	}
+	target, err := Readlink(f.fsys, full)
+	return target, f.fixErr(err)
+}
+
+// Lstat implements SymlinkFS
+func (f *subFS) Lstat(name string) (fs.FileInfo, error) {
+	full, err := f.fullName("Lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := Lstat(f.fsys, full)
+	return info, f.fixErr(err)
+}
+
+// Link implements LinkFS.
+// It returns an error wrapping an UnsupportedError
+// if the underlying fsys does not implement LinkFS.
+func (f *subFS) Link(oldname, newname string) error {
+	oldFull, err := f.fullName("Link", oldname)
+	if err != nil {
+		return err
+	}
+	newFull, err := f.fullName("Link", newname)
+	if err != nil {
+		return err
+	}
+	return f.fixErr(Link(f.fsys, oldFull, newFull))
+}
@@ -0,0 +1,132 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subWriteFS is a WriteFS whose Sub
+// method returns a WriteFS.
+type subWriteFS struct {
+	*writefs.MemFS
+	subs int
+}
+
+func (fsys *subWriteFS) Sub(dir string) (fs.FS, error) {
+	fsys.subs++
+	return newTreeFS(), nil
+}
+
+func TestSub(t *testing.T) {
+	t.Run("returns fsys for .", func(t *testing.T) {
+		fsys := newTreeFS()
+		sub, err := writefs.Sub(fsys, ".")
+		require.NoError(t, err)
+		assert.Same(t, fsys, sub)
+	})
+
+	t.Run("uses fsys.Sub when it returns a WriteFS", func(t *testing.T) {
+		fsys := &subWriteFS{MemFS: writefs.NewMemFS(nil)}
+		sub, err := writefs.Sub(fsys, "dir1")
+		require.NoError(t, err)
+		assert.IsType(t, &writefs.MemFS{}, sub)
+		assert.Equal(t, 1, fsys.subs)
+	})
+
+	t.Run("return PathError for invalid dir", func(t *testing.T) {
+		_, err := writefs.Sub(newTreeFS(), "/dir1")
+		assert.Equal(t, "Sub /dir1: invalid argument dir: not a valid path", err.Error())
+	})
+
+	for name, fsys := range map[string]func() writefs.WriteFS{
+		"MemFS":    func() writefs.WriteFS { return newTreeFS() },
+		"DirFS":    func() writefs.WriteFS { return newTreeDirFS(t) },
+		"fallback": func() writefs.WriteFS { return openFileOnlyFS{newTreeFS()} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("writes files in dir", func(t *testing.T) {
+				fsys := fsys()
+				sub, err := writefs.Sub(fsys, "dir1")
+				require.NoError(t, err)
+				_, err = writefs.WriteFile(sub, "dir2/file5", []byte("hello"))
+				require.NoError(t, err)
+				data, err := fs.ReadFile(fsys, "dir1/dir2/file5")
+				require.NoError(t, err)
+				assert.Equal(t, "hello", string(data))
+			})
+
+			t.Run("rewrites error paths", func(t *testing.T) {
+				sub, err := writefs.Sub(fsys(), "dir1")
+				require.NoError(t, err)
+				_, err = writefs.OpenFile(sub, "notexists", writefs.ReadOnly, 0)
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+				var perr *fs.PathError
+				require.ErrorAs(t, err, &perr)
+				assert.Equal(t, "notexists", perr.Path)
+
+				_, err = fs.Stat(sub, "dir2/notexists")
+				require.ErrorAs(t, err, &perr)
+				assert.Equal(t, "dir2/notexists", perr.Path)
+			})
+
+			t.Run("removes, creates and renames through helpers", func(t *testing.T) {
+				fsys := fsys()
+				sub, err := writefs.Sub(fsys, "dir1")
+				require.NoError(t, err)
+				require.NoError(t, writefs.MkDir(sub, "dir5", 0755))
+				require.NoError(t, writefs.Rename(sub, "file2", "dir5/file6"))
+				require.NoError(t, writefs.RemoveAll(sub, "dir2"))
+
+				entries, err := fs.ReadDir(fsys, "dir1")
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				assert.Equal(t, "dir5", entries[0].Name())
+				data, err := fs.ReadFile(fsys, "dir1/dir5/file6")
+				require.NoError(t, err)
+				assert.Equal(t, "ciao", string(data))
+			})
+
+			t.Run("globs and subs", func(t *testing.T) {
+				sub, err := writefs.Sub(fsys(), "dir1")
+				require.NoError(t, err)
+				matches, err := fs.Glob(sub, "*/file*")
+				require.NoError(t, err)
+				assert.Equal(t, []string{"dir2/file3"}, matches)
+
+				sub2, err := fs.Sub(sub, "dir2")
+				require.NoError(t, err)
+				data, err := fs.ReadFile(sub2, "file3")
+				require.NoError(t, err)
+				assert.Equal(t, "ciao", string(data))
+				_, ok := sub2.(writefs.WriteFS)
+				assert.True(t, ok)
+			})
+
+			t.Run("passes fstest.TestFS", func(t *testing.T) {
+				sub, err := writefs.Sub(fsys(), "dir1")
+				require.NoError(t, err)
+				assert.NoError(t, fstest.TestFS(sub, "file2", "dir2/file3"))
+			})
+		})
+	}
+
+	t.Run("WriteFileAtomic fails when fsys does not implement RenameFS", func(t *testing.T) {
+		fsys := openFileOnlyFS{newTreeFS()}
+		sub, err := writefs.Sub(fsys, "dir1")
+		require.NoError(t, err)
+		_, err = writefs.WriteFileAtomic(sub, "file2", []byte("hello"), 0644)
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+
+		entries, err := fs.ReadDir(fsys, "dir1")
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+		data, err := fs.ReadFile(fsys, "dir1/file2")
+		require.NoError(t, err)
+		assert.Equal(t, "ciao", string(data))
+	})
+}
@@ -0,0 +1,181 @@
+package writefs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// linkFS is implemented by file systems that
+// can report symbolic links, such as the ones
+// returned by DirFS.
+type linkFS interface {
+	// Lstat returns a fs.FileInfo describing the named file,
+	// without following it if it is a symbolic link.
+	Lstat(name string) (fs.FileInfo, error)
+	// Readlink returns the target of the named symbolic link.
+	Readlink(name string) (string, error)
+}
+
+// SymlinkFS is the interface implemented by a file
+// system that supports symbolic links.
+//
+// OpenFile, and all the other methods of the file system,
+// follow symbolic links, except when deleting a file using
+// the Truncate convention: in that case the link itself
+// is deleted, never its target.
+type SymlinkFS interface {
+	fs.FS
+
+	// Symlink creates newname as a symbolic link to oldname.
+	// oldname is a slash-separated path, relative
+	// to the directory containing newname.
+	// If there is an error, it will be of type *fs.PathError.
+	Symlink(oldname, newname string) error
+	// Readlink returns the target of the named symbolic link.
+	// If there is an error, it will be of type *fs.PathError.
+	Readlink(name string) (string, error)
+	// Lstat returns a fs.FileInfo describing the named file,
+	// without following it if it is a symbolic link.
+	// If there is an error, it will be of type *fs.PathError.
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// LinkFS is the interface implemented by a file
+// system that supports hard links.
+type LinkFS interface {
+	fs.FS
+
+	// Link creates newname as a hard link to the oldname file.
+	// If there is an error, it will be of type *fs.PathError.
+	Link(oldname, newname string) error
+}
+
+// validLinkTarget reports whether a symbolic link
+// named newname, pointing to oldname, stays inside
+// the file system containing it.
+func validLinkTarget(oldname, newname string) bool {
+	if oldname == "" || path.IsAbs(oldname) {
+		return false
+	}
+	return fs.ValidPath(path.Join(path.Dir(newname), oldname))
+}
+
+// checkLinkTarget checks that a symbolic link named newname,
+// relative to dir, pointing to oldname, stays inside dir, following
+// the symbolic links found along the parent directory of newname.
+func checkLinkTarget(fsys fs.FS, dir string, oldname, newname string) error {
+	if newname == "." || !validLinkTarget(oldname, newname) {
+		err := fmt.Errorf("%w target: points outside of fsys", fs.ErrInvalid)
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: err}
+	}
+	parent, err := resolveLinks(fsys, dir, "Symlink", path.Dir(newname), true)
+	if err != nil {
+		return jailError(newname, err)
+	}
+	target := path.Join(parent, oldname)
+	inside := dir == "." || target == dir || strings.HasPrefix(target, dir+"/")
+	if !inside || !fs.ValidPath(target) {
+		err := fmt.Errorf("%w target: points outside of fsys", fs.ErrInvalid)
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: err}
+	}
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+// oldname is a slash-separated path, relative to the
+// directory containing newname, that must not
+// point outside of fsys, once the symbolic links found
+// along the directory containing newname are followed.
+//
+// If fsys implements SymlinkFS, Symlink calls fsys.Symlink.
+// Otherwise, Symlink returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Symlink(fsys fs.FS, oldname, newname string) error {
+	if !fs.ValidPath(newname) || newname == "." {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: err}
+	}
+	if !validLinkTarget(oldname, newname) {
+		err := fmt.Errorf("%w target: points outside of fsys", fs.ErrInvalid)
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: err}
+	}
+
+	sfs, ok := fsys.(SymlinkFS)
+	if !ok {
+		return &fs.PathError{Op: "Symlink", Path: newname, Err: &UnsupportedError{Interface: "SymlinkFS"}}
+	}
+	if err := checkLinkTarget(fsys, ".", oldname, newname); err != nil {
+		return err
+	}
+	if err := sfs.Symlink(oldname, newname); err != nil {
+		return opPathError("Symlink", newname, err)
+	}
+	return nil
+}
+
+// Readlink returns the target of the named symbolic link.
+//
+// If fsys implements SymlinkFS, or Lstat and Readlink
+// methods, Readlink calls fsys.Readlink.
+// Otherwise, Readlink returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Readlink(fsys fs.FS, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+		return "", &fs.PathError{Op: "Readlink", Path: name, Err: err}
+	}
+
+	lfs, ok := fsys.(linkFS)
+	if !ok {
+		return "", &fs.PathError{Op: "Readlink", Path: name, Err: &UnsupportedError{Interface: "SymlinkFS"}}
+	}
+	target, err := lfs.Readlink(name)
+	if err != nil {
+		return "", opPathError("Readlink", name, err)
+	}
+	return target, nil
+}
+
+// Lstat returns a fs.FileInfo describing the named file,
+// without following it if it is a symbolic link.
+//
+// If fsys implements SymlinkFS, or Lstat and Readlink
+// methods, Lstat calls fsys.Lstat.
+// Otherwise, since fsys cannot contain symbolic
+// links, Lstat returns the result of fs.Stat.
+func Lstat(fsys fs.FS, name string) (fs.FileInfo, error) {
+	lfs, ok := fsys.(linkFS)
+	if !ok {
+		return fs.Stat(fsys, name)
+	}
+	info, err := lfs.Lstat(name)
+	if err != nil {
+		return nil, opPathError("Lstat", name, err)
+	}
+	return info, nil
+}
+
+// Link creates newname as a hard link to the oldname file.
+//
+// If fsys implements LinkFS, Link calls fsys.Link.
+// Otherwise, Link returns a *fs.PathError
+// wrapping an UnsupportedError.
+func Link(fsys fs.FS, oldname, newname string) error {
+	for _, name := range []string{oldname, newname} {
+		if !fs.ValidPath(name) || name == "." {
+			err := fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+			return &fs.PathError{Op: "Link", Path: name, Err: err}
+		}
+	}
+
+	lfs, ok := fsys.(LinkFS)
+	if !ok {
+		return &fs.PathError{Op: "Link", Path: oldname, Err: &UnsupportedError{Interface: "LinkFS"}}
+	}
+	if err := lfs.Link(oldname, newname); err != nil {
+		return opPathError("Link", oldname, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package writefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymlink(t *testing.T) {
+	// every file system contains file2 and dir2/file3
+	for name, newFS := range map[string]func(t *testing.T) writefs.WriteFS{
+		"DirFS": func(t *testing.T) writefs.WriteFS {
+			fsys := writefs.DirFS(t.TempDir())
+			require.NoError(t, writefs.MkDir(fsys, "dir2", 0755))
+			for _, name := range []string{"dir2/file3", "file2"} {
+				_, err := writefs.WriteFile(fsys, name, []byte("ciao"))
+				require.NoError(t, err)
+			}
+			return fsys
+		},
+		"Sub": func(t *testing.T) writefs.WriteFS {
+			sub, err := writefs.Sub(newTreeDirFS(t), "dir1")
+			require.NoError(t, err)
+			return sub
+		},
+		"Jail": func(t *testing.T) writefs.WriteFS {
+			jail, err := writefs.Jail(newTreeDirFS(t), "dir1")
+			require.NoError(t, err)
+			return jail
+		},
+	} {
+		t.Run(name+" follows links", func(t *testing.T) {
+			fsys := newFS(t)
+			require.NoError(t, writefs.Symlink(fsys, "file2", "link"))
+			require.NoError(t, writefs.Symlink(fsys, "../dir2", "dir2/up"))
+
+			target, err := writefs.Readlink(fsys, "link")
+			require.NoError(t, err)
+			assert.Equal(t, "file2", target)
+			info, err := writefs.Lstat(fsys, "link")
+			require.NoError(t, err)
+			assert.Equal(t, fs.ModeSymlink, info.Mode().Type())
+
+			_, err = writefs.WriteFile(fsys, "link", []byte("hello"))
+			require.NoError(t, err)
+			data, err := fs.ReadFile(fsys, "file2")
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+			data, err = fs.ReadFile(fsys, "dir2/up/file3")
+			require.NoError(t, err)
+			assert.Equal(t, "ciao", string(data))
+		})
+
+		t.Run(name+" deletes links, not targets", func(t *testing.T) {
+			fsys := newFS(t)
+			require.NoError(t, writefs.Symlink(fsys, "dir2", "dirlink"))
+			require.NoError(t, writefs.Symlink(fsys, "file2", "link"))
+
+			_, err := writefs.OpenFile(fsys, "dirlink", writefs.Truncate, 0)
+			require.NoError(t, err)
+			require.NoError(t, writefs.Remove(fsys, "link"))
+
+			for _, name := range []string{"dirlink", "link"} {
+				_, err = writefs.Lstat(fsys, name)
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+			}
+			for _, name := range []string{"dir2/file3", "file2"} {
+				_, err = fs.Stat(fsys, name)
+				assert.NoError(t, err)
+			}
+		})
+
+		t.Run(name+" creates hard links", func(t *testing.T) {
+			fsys := newFS(t)
+			require.NoError(t, writefs.Link(fsys, "file2", "hard"))
+			_, err := writefs.AppendFile(fsys, "hard", []byte("!"))
+			require.NoError(t, err)
+			data, err := fs.ReadFile(fsys, "file2")
+			require.NoError(t, err)
+			assert.Equal(t, "ciao!", string(data))
+		})
+
+		t.Run(name+" refuses targets outside of fsys", func(t *testing.T) {
+			fsys := newFS(t)
+			for _, target := range []string{"../file1", "/file2", "dir2/../../file1", ""} {
+				err := writefs.Symlink(fsys, target, "link")
+				assert.ErrorIs(t, err, fs.ErrInvalid, target)
+				var perr *fs.PathError
+				assert.ErrorAs(t, err, &perr)
+			}
+		})
+		t.Run(name+" refuses targets escaping through links", func(t *testing.T) {
+			fsys := newFS(t)
+			require.NoError(t, writefs.Symlink(fsys, ".", "self"))
+			for _, err := range []error{
+				writefs.Symlink(fsys, "../file1", "self/link"),
+				fsys.(writefs.SymlinkFS).Symlink("../file1", "self/link"),
+			} {
+				assert.Error(t, err)
+				var perr *fs.PathError
+				assert.ErrorAs(t, err, &perr)
+			}
+			_, err := writefs.Lstat(fsys, "link")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+
+	t.Run("Jail refuses targets outside of dir", func(t *testing.T) {
+		jail, err := writefs.Jail(newTreeDirFS(t), "dir1")
+		require.NoError(t, err)
+		err = jail.(writefs.SymlinkFS).Symlink("../file1", "link")
+		assert.ErrorIs(t, err, fs.ErrPermission)
+	})
+
+	t.Run("return UnsupportedError", func(t *testing.T) {
+		fsys := fstest.MapFS{"file1": {}}
+		for _, err := range []error{
+			writefs.Symlink(fsys, "file1", "link"),
+			writefs.Link(fsys, "file1", "link"),
+			func() error { _, err := writefs.Readlink(fsys, "file1"); return err }(),
+		} {
+			var uerr *writefs.UnsupportedError
+			assert.True(t, errors.As(err, &uerr))
+			var perr *fs.PathError
+			assert.ErrorAs(t, err, &perr)
+		}
+
+		info, err := writefs.Lstat(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "file1", info.Name())
+	})
+}
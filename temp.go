@@ -0,0 +1,124 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// tempPattern splits pattern into the prefix and the suffix
+// surrounding the last "*", or returns pattern as prefix if
+// it contains no "*".
+func tempPattern(op string, pattern string) (prefix, suffix string, err error) {
+	if strings.Contains(pattern, "/") {
+		err := fmt.Errorf("%w pattern: contains path separator", fs.ErrInvalid)
+		return "", "", &fs.PathError{Op: op, Path: pattern, Err: err}
+	}
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		return pattern[:i], pattern[i+1:], nil
+	}
+	return pattern, "", nil
+}
+
+// tempNames returns a function that builds a new
+// temporary name in dir, following pattern, on each call.
+func tempNames(op string, dir string, pattern string) (func() string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	if !fs.ValidPath(dir) {
+		err := fmt.Errorf("%w dir: not a valid path", fs.ErrInvalid)
+		return nil, &fs.PathError{Op: op, Path: dir, Err: err}
+	}
+	prefix, suffix, err := tempPattern(op, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func() string {
+		return path.Join(dir, prefix+nextRandom()+suffix)
+	}, nil
+}
+
+// createTempFile creates a new file, named by next and opened with
+// access mode flag, trying another name while OpenFile fails
+// with an error wrapping fs.ErrExist.
+func createTempFile(fsys fs.FS, next func() string, flag Flag, perm fs.FileMode) (FileWriter, string, error) {
+	for i := 0; i < maxTempAttempts; i++ {
+		name := next()
+		file, err := OpenFile(fsys, name, flag|Create|Exclusive, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return file, name, err
+	}
+	return nil, "", fmt.Errorf("%w temporary file: too many attempts", fs.ErrExist)
+}
+
+// CreateTemp creates a new temporary file in directory dir,
+// opens it for reading and writing, and returns the
+// resulting file and its name.
+// It mirrors os.CreateTemp semantics.
+//
+// The name is generated by taking pattern and adding a random
+// string to the end. If pattern includes a "*", the random string
+// replaces the last "*". If dir is the empty string, CreateTemp
+// uses the root of fsys. dir must exist.
+//
+// The file is created with mode 0600 by calling OpenFile with
+// ReadWrite|Create|Exclusive flags, trying another name while
+// OpenFile fails with an error wrapping fs.ErrExist.
+// It is the caller's responsibility to remove
+// the file when it is no longer needed.
+//
+// If there is an error, it will be of type *fs.PathError.
+func CreateTemp(fsys fs.FS, dir, pattern string) (FileWriter, string, error) {
+	next, err := tempNames("CreateTemp", dir, pattern)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, name, err := createTempFile(fsys, next, ReadWrite, 0600)
+	if err != nil {
+		return nil, "", opPathError("CreateTemp", path.Join(dir, pattern), err)
+	}
+	return file, name, nil
+}
+
+// MkdirTemp creates a new temporary directory in directory dir
+// and returns its name.
+// It mirrors os.MkdirTemp semantics.
+//
+// The name is generated by taking pattern and applying a random
+// string to the end. If pattern includes a "*", the random string
+// replaces the last "*". If dir is the empty string, MkdirTemp
+// uses the root of fsys. dir must exist.
+//
+// The directory is created with mode 0700 by calling MkDir,
+// trying another name while MkDir fails with an error
+// wrapping fs.ErrExist.
+// It is the caller's responsibility to remove
+// the directory when it is no longer needed.
+//
+// If there is an error, it will be of type *fs.PathError.
+func MkdirTemp(fsys fs.FS, dir, pattern string) (string, error) {
+	next, err := tempNames("MkdirTemp", dir, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i < maxTempAttempts; i++ {
+		name := next()
+		err := MkDir(fsys, name, 0700)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", opPathError("MkdirTemp", name, err)
+		}
+		return name, nil
+	}
+	err = fmt.Errorf("%w temporary directory: too many attempts", fs.ErrExist)
+	return "", &fs.PathError{Op: "MkdirTemp", Path: path.Join(dir, pattern), Err: err}
+}
@@ -0,0 +1,85 @@
+package writefs_test
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTemp(t *testing.T) {
+	for name, newFS := range map[string]func(t *testing.T) writefs.WriteFS{
+		"MemFS": func(t *testing.T) writefs.WriteFS { return newTreeFS() },
+		"DirFS": newTreeDirFS,
+	} {
+		t.Run(name+" creates files", func(t *testing.T) {
+			fsys := newFS(t)
+			file, tmpName, err := writefs.CreateTemp(fsys, "dir1", "tmp*.txt")
+			require.NoError(t, err)
+			_, err = file.Write([]byte("hello"))
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+
+			assert.Equal(t, "dir1", path.Dir(tmpName))
+			assert.True(t, strings.HasPrefix(path.Base(tmpName), "tmp"))
+			assert.True(t, strings.HasSuffix(tmpName, ".txt"))
+			data, err := fs.ReadFile(fsys, tmpName)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+
+			file, other, err := writefs.CreateTemp(fsys, "", "tmp")
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+			assert.True(t, strings.HasPrefix(other, "tmp"))
+			assert.NotContains(t, other, "/")
+		})
+
+		t.Run(name+" creates directories", func(t *testing.T) {
+			fsys := newFS(t)
+			dir, err := writefs.MkdirTemp(fsys, "dir1", "*-work")
+			require.NoError(t, err)
+			assert.Equal(t, "dir1", path.Dir(dir))
+			assert.True(t, strings.HasSuffix(dir, "-work"))
+			info, err := fs.Stat(fsys, dir)
+			require.NoError(t, err)
+			assert.True(t, info.IsDir())
+		})
+
+		t.Run(name+" requires dir to exist", func(t *testing.T) {
+			fsys := newFS(t)
+			_, _, err := writefs.CreateTemp(fsys, "not-existent", "tmp")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			_, err = writefs.MkdirTemp(fsys, "not-existent", "tmp")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+
+	t.Run("retries on fs.ErrExist", func(t *testing.T) {
+		var attempts []string
+		fsys := writefs.WithHooks(newTreeFS(), writefs.Hooks{
+			BeforeOpen: func(name string, flag writefs.Flag, perm fs.FileMode) error {
+				attempts = append(attempts, name)
+				if len(attempts) < 3 {
+					return fs.ErrExist
+				}
+				return nil
+			},
+		})
+		file, tmpName, err := writefs.CreateTemp(fsys, "dir1", "tmp")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		require.Len(t, attempts, 3)
+		assert.Equal(t, attempts[2], tmpName)
+	})
+
+	t.Run("return PathError for invalid arguments", func(t *testing.T) {
+		_, _, err := writefs.CreateTemp(newTreeFS(), "dir1", "a/b*")
+		assert.EqualError(t, err, "CreateTemp a/b*: invalid argument pattern: contains path separator")
+		_, err = writefs.MkdirTemp(newTreeFS(), "../dir1", "tmp")
+		assert.EqualError(t, err, "MkdirTemp ../dir1: invalid argument dir: not a valid path")
+	})
+}
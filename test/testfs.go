@@ -0,0 +1,394 @@
+// Package test implements support for testing
+// implementations of writefs.WriteFS.
+//
+// It is similar to testing/fstest, and
+// also runs fstest.TestFS on the files it creates.
+package test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+)
+
+// TestWriteFS tests a writefs.WriteFS implementation.
+// It creates files and directories inside scratchDir,
+// checking that every Flag combination and the directory
+// creation and deletion conventions of WriteFS.OpenFile are
+// honored, and that errors are of type *fs.PathError wrapping
+// the expected fs error.
+//
+// After these checks, it runs fstest.TestFS on scratchDir
+// and finally deletes scratchDir.
+//
+// scratchDir is created if it does not exist, and should be empty.
+// It cannot be the root directory ".", since it is deleted at the end.
+// If TestWriteFS finds any misbehaviors, it returns an error
+// reporting all of them.
+func TestWriteFS(fsys writefs.WriteFS, scratchDir string) error {
+	t := tester{fsys: fsys, dir: scratchDir}
+
+	if !t.mkdir(scratchDir) {
+		return t.result()
+	}
+
+	t.checkMkdir()
+	t.checkCreate()
+	t.checkExclusive()
+	t.checkNotExist()
+	t.checkTruncate()
+	t.checkAppend()
+	t.checkReadWrite()
+	t.checkReadWriteAppend()
+	t.checkReadWriteTruncate()
+	t.checkReadOnly()
+	t.checkSynchronous()
+	t.checkInvalidPath()
+	t.checkDelete()
+
+	sub, err := fs.Sub(fsys, scratchDir)
+	if err != nil {
+		t.errorf("fs.Sub(fsys, %s): %v", scratchDir, err)
+	} else if err := fstest.TestFS(sub, t.expected...); err != nil {
+		t.errorf("testing fs.Sub(fsys, %s): %v", scratchDir, err)
+	}
+
+	if t.remove(scratchDir) {
+		t.checkMissing(scratchDir)
+	}
+
+	return t.result()
+}
+
+// tester accumulates errors found
+// while testing a writefs.WriteFS.
+type tester struct {
+	fsys     writefs.WriteFS
+	dir      string
+	errs     []string
+	expected []string
+}
+
+func (t *tester) errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func (t *tester) result() error {
+	if len(t.errs) == 0 {
+		return nil
+	}
+	return errors.New("TestWriteFS found errors:\n" + strings.Join(t.errs, "\n"))
+}
+
+// path returns name joined with scratch directory.
+func (t *tester) path(name string) string {
+	return path.Join(t.dir, name)
+}
+
+// expect adds name to the list of files
+// checked by fstest.TestFS
+func (t *tester) expect(name string) {
+	t.expected = append(t.expected, name)
+}
+
+// checkPathError checks that err is a *fs.PathError
+// that wraps target.
+func (t *tester) checkPathError(desc string, name string, err error, target error) {
+	if err == nil {
+		t.errorf("%s %s: expected error %v, got nil", desc, name, target)
+		return
+	}
+	if _, ok := err.(*fs.PathError); !ok {
+		t.errorf("%s %s: expected *fs.PathError, got %T: %v", desc, name, err, err)
+	}
+	if !errors.Is(err, target) {
+		t.errorf("%s %s: expected error %v, got %v", desc, name, target, err)
+	}
+}
+
+// open calls OpenFile and reports any error
+func (t *tester) open(name string, flag writefs.Flag, perm fs.FileMode) writefs.FileWriter {
+	f, err := t.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		t.errorf("OpenFile %s with %s: %v", name, flag, err)
+		return nil
+	}
+	if f == nil {
+		t.errorf("OpenFile %s with %s: returned nil FileWriter", name, flag)
+	}
+	return f
+}
+
+// write opens name with flag, writes data and closes it.
+func (t *tester) write(name string, flag writefs.Flag, data string) bool {
+	f := t.open(name, flag, 0644)
+	if f == nil {
+		return false
+	}
+	ok := true
+	if n, err := f.Write([]byte(data)); err != nil || n != len(data) {
+		t.errorf("Write %s with %s: wrote %d bytes of %d: %v", name, flag, n, len(data), err)
+		ok = false
+	}
+	if err := f.Close(); err != nil {
+		t.errorf("Close %s with %s: %v", name, flag, err)
+		ok = false
+	}
+	return ok
+}
+
+// checkContent verifies that name contains data
+func (t *tester) checkContent(desc string, name string, data string) {
+	actual, err := fs.ReadFile(t.fsys, name)
+	if err != nil {
+		t.errorf("%s: ReadFile %s: %v", desc, name, err)
+		return
+	}
+	if !bytes.Equal(actual, []byte(data)) {
+		t.errorf("%s: ReadFile %s: expected %q, got %q", desc, name, data, actual)
+	}
+}
+
+// checkMissing verifies that name does not exist
+func (t *tester) checkMissing(name string) {
+	_, err := fs.Stat(t.fsys, name)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("Stat %s: expected %v, got %v", name, fs.ErrNotExist, err)
+	}
+}
+
+// mkdir creates a directory using the OpenFile convention.
+func (t *tester) mkdir(name string) bool {
+	f, err := t.fsys.OpenFile(name, writefs.Create, fs.ModeDir|0755)
+	if err != nil {
+		t.errorf("OpenFile %s with Create and fs.ModeDir: %v", name, err)
+		return false
+	}
+	if f != nil {
+		t.errorf("OpenFile %s with Create and fs.ModeDir: expected nil FileWriter, got %T", name, f)
+		f.Close()
+	}
+	info, err := fs.Stat(t.fsys, name)
+	if err != nil {
+		t.errorf("Stat %s: %v", name, err)
+		return false
+	}
+	if !info.IsDir() {
+		t.errorf("Stat %s: expected a directory, got mode %v", name, info.Mode())
+		return false
+	}
+	return true
+}
+
+// remove deletes a file or directory using the OpenFile convention.
+func (t *tester) remove(name string) bool {
+	f, err := t.fsys.OpenFile(name, writefs.Truncate, 0)
+	if err != nil {
+		t.errorf("OpenFile %s with Truncate: %v", name, err)
+		return false
+	}
+	if f != nil {
+		t.errorf("OpenFile %s with Truncate: expected nil FileWriter, got %T", name, f)
+		f.Close()
+	}
+	return true
+}
+
+func (t *tester) checkMkdir() {
+	if !t.mkdir(t.path("mkdir/sub1/sub2")) {
+		return
+	}
+	t.expect("mkdir/sub1/sub2")
+
+	if !t.mkdir(t.path("mkdir/sub1")) {
+		return
+	}
+
+	_, err := t.fsys.OpenFile(t.path("mkdir/sub1"), writefs.Create|writefs.Exclusive, fs.ModeDir|0755)
+	t.checkPathError("OpenFile with Create|Exclusive and fs.ModeDir", t.path("mkdir/sub1"), err, fs.ErrExist)
+}
+
+func (t *tester) checkCreate() {
+	name := t.path("create")
+	if t.write(name, writefs.WriteOnly|writefs.Create, "create") {
+		t.checkContent("WriteOnly|Create", name, "create")
+		t.expect("create")
+	}
+
+	_, err := t.fsys.OpenFile(t.path("missing/create"), writefs.WriteOnly|writefs.Create, 0644)
+	t.checkPathError("OpenFile with WriteOnly|Create in missing directory", t.path("missing/create"), err, fs.ErrNotExist)
+}
+
+func (t *tester) checkExclusive() {
+	name := t.path("exclusive")
+	if !t.write(name, writefs.WriteOnly|writefs.Create|writefs.Exclusive, "exclusive") {
+		return
+	}
+	t.checkContent("WriteOnly|Create|Exclusive", name, "exclusive")
+	t.expect("exclusive")
+
+	_, err := t.fsys.OpenFile(name, writefs.WriteOnly|writefs.Create|writefs.Exclusive, 0644)
+	t.checkPathError("OpenFile with WriteOnly|Create|Exclusive on existing file", name, err, fs.ErrExist)
+	t.checkContent("WriteOnly|Create|Exclusive on existing file", name, "exclusive")
+}
+
+func (t *tester) checkNotExist() {
+	name := t.path("notexist")
+	_, err := t.fsys.OpenFile(name, writefs.WriteOnly, 0644)
+	t.checkPathError("OpenFile with WriteOnly on missing file", name, err, fs.ErrNotExist)
+	_, err = t.fsys.OpenFile(name, writefs.ReadOnly, 0)
+	t.checkPathError("OpenFile with ReadOnly on missing file", name, err, fs.ErrNotExist)
+	t.checkMissing(name)
+}
+
+func (t *tester) checkTruncate() {
+	name := t.path("truncate")
+	if !t.write(name, writefs.WriteOnly|writefs.Create, "a long content") {
+		return
+	}
+	t.expect("truncate")
+	if t.write(name, writefs.WriteOnly|writefs.Truncate, "short") {
+		t.checkContent("WriteOnly|Truncate", name, "short")
+	}
+	if t.write(name, writefs.WriteOnly, "S") {
+		t.checkContent("WriteOnly without Truncate", name, "Short")
+	}
+	if t.write(name, writefs.WriteOnly|writefs.Create|writefs.Truncate, "truncate") {
+		t.checkContent("WriteOnly|Create|Truncate", name, "truncate")
+	}
+}
+
+func (t *tester) checkAppend() {
+	name := t.path("append")
+	if !t.write(name, writefs.WriteOnly|writefs.Create|writefs.Append, "one") {
+		return
+	}
+	t.expect("append")
+	if t.write(name, writefs.WriteOnly|writefs.Append, ",two") {
+		t.checkContent("WriteOnly|Append", name, "one,two")
+	}
+}
+
+func (t *tester) checkReadWrite() {
+	name := t.path("readwrite")
+	if !t.write(name, writefs.ReadWrite|writefs.Create, "read") {
+		return
+	}
+	t.expect("readwrite")
+
+	f := t.open(name, writefs.ReadWrite, 0)
+	if f == nil {
+		return
+	}
+
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		t.errorf("Read %s with ReadWrite: %v", name, err)
+	} else if string(buf) != "read" {
+		t.errorf("Read %s with ReadWrite: expected %q, got %q", name, "read", buf)
+	} else if _, err := f.Write([]byte("write")); err != nil {
+		t.errorf("Write %s with ReadWrite: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		t.errorf("Close %s with ReadWrite: %v", name, err)
+		return
+	}
+	t.checkContent("ReadWrite", name, "readwrite")
+}
+
+func (t *tester) checkReadWriteAppend() {
+	name := t.path("readwriteappend")
+	if !t.write(name, writefs.ReadWrite|writefs.Create|writefs.Append, "one") {
+		return
+	}
+	t.checkContent("ReadWrite|Create|Append", name, "one")
+	t.expect("readwriteappend")
+	if t.write(name, writefs.ReadWrite|writefs.Append, ",two") {
+		t.checkContent("ReadWrite|Append", name, "one,two")
+	}
+}
+
+func (t *tester) checkReadWriteTruncate() {
+	name := t.path("readwritetruncate")
+	if !t.write(name, writefs.ReadWrite|writefs.Create|writefs.Truncate, "a long content") {
+		return
+	}
+	t.checkContent("ReadWrite|Create|Truncate", name, "a long content")
+	t.expect("readwritetruncate")
+
+	f := t.open(name, writefs.ReadWrite|writefs.Truncate, 0)
+	if f == nil {
+		return
+	}
+	if data, err := io.ReadAll(f); err != nil || len(data) != 0 {
+		t.errorf("Read %s with ReadWrite|Truncate: expected empty file, got %q: %v", name, data, err)
+	} else if _, err := f.Write([]byte("short")); err != nil {
+		t.errorf("Write %s with ReadWrite|Truncate: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		t.errorf("Close %s with ReadWrite|Truncate: %v", name, err)
+		return
+	}
+	t.checkContent("ReadWrite|Truncate", name, "short")
+}
+
+func (t *tester) checkReadOnly() {
+	name := t.path("readonly")
+	if !t.write(name, writefs.WriteOnly|writefs.Create, "readonly") {
+		return
+	}
+	t.expect("readonly")
+
+	f := t.open(name, writefs.ReadOnly, 0)
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	if data, err := io.ReadAll(f); err != nil || string(data) != "readonly" {
+		t.errorf("Read %s with ReadOnly: expected %q, got %q: %v", name, "readonly", data, err)
+	}
+	if _, err := f.Write([]byte("write")); err == nil {
+		t.errorf("Write %s with ReadOnly: expected error, got nil", name)
+	}
+	t.checkContent("Write with ReadOnly", name, "readonly")
+}
+
+func (t *tester) checkSynchronous() {
+	name := t.path("synchronous")
+	if t.write(name, writefs.WriteOnly|writefs.Create|writefs.Synchronous, "synchronous") {
+		t.checkContent("WriteOnly|Create|Synchronous", name, "synchronous")
+		t.expect("synchronous")
+	}
+}
+
+func (t *tester) checkInvalidPath() {
+	for _, name := range []string{"/" + t.dir + "/invalid", t.dir + "/../invalid", t.dir + "/invalid/"} {
+		_, err := t.fsys.OpenFile(name, writefs.WriteOnly|writefs.Create, 0644)
+		t.checkPathError("OpenFile with invalid path", name, err, fs.ErrInvalid)
+	}
+}
+
+func (t *tester) checkDelete() {
+	file := t.path("delete")
+	if t.write(file, writefs.WriteOnly|writefs.Create, "delete") && t.remove(file) {
+		t.checkMissing(file)
+	}
+
+	dir := t.path("deletedir")
+	if t.mkdir(path.Join(dir, "sub")) &&
+		t.write(path.Join(dir, "sub", "file"), writefs.WriteOnly|writefs.Create, "delete") &&
+		t.remove(dir) {
+		t.checkMissing(dir)
+	}
+
+	_, err := t.fsys.OpenFile(file, writefs.Truncate, 0)
+	t.checkPathError("OpenFile with Truncate on missing file", file, err, fs.ErrNotExist)
+}
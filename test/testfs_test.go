@@ -0,0 +1,75 @@
+package test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notExclusiveFS is a broken WriteFS
+// that ignores Exclusive flag.
+type notExclusiveFS struct {
+	*writefs.MemFS
+}
+
+func (fsys notExclusiveFS) OpenFile(name string, flag writefs.Flag, perm fs.FileMode) (writefs.FileWriter, error) {
+	return fsys.MemFS.OpenFile(name, flag&^writefs.Exclusive, perm)
+}
+
+func TestTestWriteFS(t *testing.T) {
+	t.Run("MemFS", func(t *testing.T) {
+		assert.NoError(t, TestWriteFS(writefs.NewMemFS(nil), "scratch"))
+	})
+
+	t.Run("DirFS", func(t *testing.T) {
+		assert.NoError(t, TestWriteFS(writefs.DirFS(t.TempDir()), "scratch/dir"))
+	})
+
+	t.Run("Sub", func(t *testing.T) {
+		sub, err := writefs.Sub(writefs.NewMemFS(nil), "root")
+		require.NoError(t, err)
+		assert.NoError(t, TestWriteFS(sub, "scratch"))
+	})
+
+	t.Run("Overlay", func(t *testing.T) {
+		base := fstest.MapFS{
+			"scratch/base": &fstest.MapFile{Data: []byte("base")},
+		}
+		assert.NoError(t, TestWriteFS(writefs.Overlay(base, writefs.NewMemFS(nil)), "scratch"))
+	})
+
+	t.Run("Mount", func(t *testing.T) {
+		m := &writefs.Mount{}
+		require.NoError(t, m.Mount("scratch", writefs.NewMemFS(nil)))
+		assert.NoError(t, TestWriteFS(m, "scratch/dir"))
+	})
+
+	t.Run("Jail", func(t *testing.T) {
+		fsys, err := writefs.Jail(writefs.DirFS(t.TempDir()), ".")
+		require.NoError(t, err)
+		assert.NoError(t, TestWriteFS(fsys, "scratch"))
+	})
+
+	t.Run("WithQuota", func(t *testing.T) {
+		assert.NoError(t, TestWriteFS(writefs.WithQuota(writefs.NewMemFS(nil), 1<<20, 100), "scratch"))
+	})
+
+	t.Run("WithHooks", func(t *testing.T) {
+		assert.NoError(t, TestWriteFS(writefs.WithHooks(writefs.NewMemFS(nil), writefs.Hooks{}), "scratch"))
+	})
+
+	t.Run("WithWatch", func(t *testing.T) {
+		assert.NoError(t, TestWriteFS(writefs.WithWatch(writefs.NewMemFS(nil)), "scratch"))
+	})
+
+	t.Run("reports all errors", func(t *testing.T) {
+		err := TestWriteFS(notExclusiveFS{writefs.NewMemFS(nil)}, "scratch")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "OpenFile with Create|Exclusive and fs.ModeDir scratch/mkdir/sub1: expected error file already exists, got nil")
+		assert.Contains(t, err.Error(), "OpenFile with WriteOnly|Create|Exclusive on existing file scratch/exclusive: expected error file already exists, got nil")
+	})
+}
@@ -0,0 +1,359 @@
+package writefs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// EventOp describes the kind of change reported by an Event.
+type EventOp int
+
+const (
+	// EventCreate reports that a file has been created.
+	EventCreate EventOp = iota + 1
+	// EventWrite reports that the content of a file changed.
+	EventWrite
+	// EventRemove reports that a file or
+	// a directory, with all its content, has been removed.
+	EventRemove
+	// EventMkdir reports that a directory has been created.
+	EventMkdir
+)
+
+// String returns the name of op.
+func (op EventOp) String() string {
+	switch op {
+	case EventCreate:
+		return "Create"
+	case EventWrite:
+		return "Write"
+	case EventRemove:
+		return "Remove"
+	case EventMkdir:
+		return "Mkdir"
+	}
+	return fmt.Sprintf("EventOp(%d)", int(op))
+}
+
+// Event describes a change to a file system.
+type Event struct {
+	// Name is the name of the changed file.
+	Name string
+	// Op is the kind of change.
+	Op EventOp
+}
+
+// WatchFS is the interface implemented by a file
+// system that can notify changes to its files.
+type WatchFS interface {
+	fs.FS
+
+	// Watch returns a channel that receives an Event for
+	// each change to name or, if name is a directory, to its
+	// direct children. If recursive is true, changes to all
+	// the files contained in name are notified too.
+	// name does not need to exist when Watch is called.
+	//
+	// The returned function stops the notifications
+	// and closes the channel.
+	Watch(name string, recursive bool) (<-chan Event, func())
+}
+
+// Watcher is a WriteFS that forwards every operation
+// to another WriteFS, and implements WatchFS reporting
+// the changes done through it.
+//
+// OpenFile reports EventCreate when it creates a file,
+// EventMkdir for every directory created using the Create
+// convention, and EventRemove when deleting a file or
+// a directory using the Truncate convention.
+// EventWrite is reported when a file opened with WriteOnly
+// or ReadWrite flags is closed, if it has been written or
+// truncated. Rename reports EventRemove for oldname and
+// EventCreate for newname.
+//
+// Changes done directly on the wrapped file
+// system are not reported.
+// Events are queued without limits,
+// so slow receivers never block writers.
+//
+// A Watcher must be created using WithWatch:
+// its zero value is not ready to use.
+type Watcher struct {
+	fsys WriteFS
+
+	mu      sync.Mutex
+	watches map[*watch]struct{}
+}
+
+var (
+	_ WriteFS        = &Watcher{}
+	_ WatchFS        = &Watcher{}
+	_ RenameFS       = &Watcher{}
+	_ CapabilitiesFS = &Watcher{}
+	_ linkFS         = &Watcher{}
+)
+
+// WithWatch returns a Watcher that wraps fsys.
+func WithWatch(fsys WriteFS) *Watcher {
+	return &Watcher{fsys: fsys, watches: map[*watch]struct{}{}}
+}
+
+// watch is a single subscription
+// created by Watcher.Watch.
+type watch struct {
+	name      string
+	recursive bool
+	ch        chan Event
+	notify    chan struct{}
+	done      chan struct{}
+
+	mu    sync.Mutex
+	queue []Event
+}
+
+// matches reports whether ev should be sent to w.
+func (w *watch) matches(ev Event) bool {
+	switch {
+	case ev.Name == w.name:
+		return true
+	case ev.Op == EventRemove && (ev.Name == "." || strings.HasPrefix(w.name, ev.Name+"/")):
+		return true
+	case w.recursive:
+		return w.name == "." || strings.HasPrefix(ev.Name, w.name+"/")
+	}
+	return path.Dir(ev.Name) == w.name
+}
+
+// push queues ev, without blocking.
+func (w *watch) push(ev Event) {
+	w.mu.Lock()
+	w.queue = append(w.queue, ev)
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run sends queued events to w.ch
+// until the watch is stopped.
+func (w *watch) run() {
+	defer close(w.ch)
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			select {
+			case <-w.notify:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+		ev := w.queue[0]
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+
+		select {
+		case w.ch <- ev:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Watch implements WatchFS
+func (wfs *Watcher) Watch(name string, recursive bool) (<-chan Event, func()) {
+	w := &watch{
+		name:      name,
+		recursive: recursive,
+		ch:        make(chan Event),
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	wfs.mu.Lock()
+	wfs.watches[w] = struct{}{}
+	wfs.mu.Unlock()
+	go w.run()
+
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			wfs.mu.Lock()
+			delete(wfs.watches, w)
+			wfs.mu.Unlock()
+			close(w.done)
+		})
+	}
+}
+
+// emit sends ev to every matching watch.
+func (wfs *Watcher) emit(name string, op EventOp) {
+	ev := Event{Name: name, Op: op}
+	wfs.mu.Lock()
+	defer wfs.mu.Unlock()
+	for w := range wfs.watches {
+		if w.matches(ev) {
+			w.push(ev)
+		}
+	}
+}
+
+// Capabilities implements CapabilitiesFS.
+// Capabilities of the underlying fsys are reported,
+// limited to the ones Watcher forwards.
+func (wfs *Watcher) Capabilities() Capability {
+	return Capabilities(wfs.fsys) & wrapperCaps
+}
+
+// Open implements fs.FS
+func (wfs *Watcher) Open(name string) (fs.File, error) {
+	return wfs.fsys.Open(name)
+}
+
+// Lstat returns a fs.FileInfo describing the named file of the
+// underlying fsys, without following it if it is a symbolic link.
+func (wfs *Watcher) Lstat(name string) (fs.FileInfo, error) {
+	return Lstat(wfs.fsys, name)
+}
+
+// Readlink returns the target of the named
+// symbolic link of the underlying fsys.
+func (wfs *Watcher) Readlink(name string) (string, error) {
+	return Readlink(wfs.fsys, name)
+}
+
+// OpenFile implements WriteFS
+func (wfs *Watcher) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	switch {
+	case isMkdir(flag, perm):
+		var missing []string
+		for dir := name; dir != "."; dir = path.Dir(dir) {
+			if _, err := fs.Stat(wfs.fsys, dir); err == nil {
+				break
+			}
+			missing = append(missing, dir)
+		}
+		if _, err := wfs.fsys.OpenFile(name, flag, perm); err != nil {
+			return nil, err
+		}
+		for i := len(missing) - 1; i >= 0; i-- {
+			wfs.emit(missing[i], EventMkdir)
+		}
+		return nil, nil
+	case isRemove(flag):
+		if _, err := wfs.fsys.OpenFile(name, flag, perm); err != nil {
+			return nil, err
+		}
+		wfs.emit(name, EventRemove)
+		return nil, nil
+	case flag.access() == ReadOnly && flag&Create == 0:
+		return wfs.fsys.OpenFile(name, flag, perm)
+	}
+
+	_, err := fs.Stat(wfs.fsys, name)
+	created := errors.Is(err, fs.ErrNotExist)
+	file, err := wfs.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		wfs.emit(name, EventCreate)
+	}
+	if flag.access() == ReadOnly {
+		return file, nil
+	}
+	return &watchFile{
+		FileWriter: file,
+		fsys:       wfs,
+		name:       name,
+		changed:    !created && flag&Truncate != 0,
+	}, nil
+}
+
+// Rename implements RenameFS.
+// It returns an error wrapping errUnsupported
+// if the underlying fsys does not implement RenameFS.
+func (wfs *Watcher) Rename(oldname, newname string) error {
+	rfs, ok := wfs.fsys.(RenameFS)
+	if !ok {
+		return &fs.PathError{Op: "Rename", Path: oldname, Err: errUnsupported}
+	}
+	if err := rfs.Rename(oldname, newname); err != nil {
+		return err
+	}
+	wfs.emit(oldname, EventRemove)
+	wfs.emit(newname, EventCreate)
+	return nil
+}
+
+// watchFile is a file opened for
+// writing through a Watcher.
+type watchFile struct {
+	FileWriter
+	fsys    *Watcher
+	name    string
+	changed bool
+}
+
+// Write implements io.Writer
+func (f *watchFile) Write(p []byte) (int, error) {
+	n, err := f.FileWriter.Write(p)
+	if n > 0 {
+		f.changed = true
+	}
+	return n, err
+}
+
+// Close implements fs.File
+func (f *watchFile) Close() error {
+	err := f.FileWriter.Close()
+	if f.changed {
+		f.changed = false
+		f.fsys.emit(f.name, EventWrite)
+	}
+	return err
+}
+
+// Seek implements io.Seeker.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.Seeker.
+func (f *watchFile) Seek(offset int64, whence int) (int64, error) {
+	return seekFile(f.FileWriter, f.name, offset, whence)
+}
+
+// WriteAt implements io.WriterAt.
+// It returns an error wrapping an UnsupportedError
+// if the underlying file does not implement io.WriterAt.
+func (f *watchFile) WriteAt(p []byte, off int64) (int, error) {
+	w, ok := f.FileWriter.(io.WriterAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "WriteAt", Path: f.name, Err: &UnsupportedError{Interface: "WriterAtFile"}}
+	}
+	n, err := w.WriteAt(p, off)
+	if n > 0 {
+		f.changed = true
+	}
+	return n, err
+}
+
+// Truncate implements TruncaterFile
+func (f *watchFile) Truncate(size int64) error {
+	err := truncateFile(f.FileWriter, f.name, size)
+	if err == nil {
+		f.changed = true
+	}
+	return err
+}
+
+// Sync implements SyncerFile
+func (f *watchFile) Sync() error {
+	return syncFile(f.FileWriter, f.name)
+}
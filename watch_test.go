@@ -0,0 +1,125 @@
+package writefs_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/parrogo/writefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive returns the events received from ch, up to and
+// including last, failing t if last does not arrive in time.
+func receive(t *testing.T, ch <-chan writefs.Event, last string) []string {
+	t.Helper()
+	var events []string
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed waiting for %q, received %q", last, events)
+			}
+			events = append(events, ev.Op.String()+" "+ev.Name)
+			if events[len(events)-1] == last {
+				return events
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for %q, received %q", last, events)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	t.Run("reports changes", func(t *testing.T) {
+		fsys := writefs.WithWatch(newTreeFS())
+		ch, stop := fsys.Watch(".", true)
+		defer stop()
+
+		_, err := writefs.WriteFile(fsys, "dir1/file5", []byte("hello"))
+		require.NoError(t, err)
+		_, err = writefs.WriteFile(fsys, "file1", []byte("hello"))
+		require.NoError(t, err)
+		require.NoError(t, writefs.MkdirAll(fsys, "dir4/dir5/dir6", 0755))
+		require.NoError(t, writefs.Rename(fsys, "file1", "dir4/file1"))
+		require.NoError(t, writefs.RemoveAll(fsys, "dir1"))
+
+		assert.Equal(t, []string{
+			"Create dir1/file5",
+			"Write dir1/file5",
+			"Write file1",
+			"Mkdir dir4/dir5",
+			"Mkdir dir4/dir5/dir6",
+			"Remove file1",
+			"Create dir4/file1",
+			"Remove dir1",
+		}, receive(t, ch, "Remove dir1"))
+	})
+
+	t.Run("filters events", func(t *testing.T) {
+		fsys := writefs.WithWatch(newTreeFS())
+		file, stopFile := fsys.Watch("dir1/file2", false)
+		defer stopFile()
+		dir, stopDir := fsys.Watch("dir1", false)
+		defer stopDir()
+		tree, stopTree := fsys.Watch("dir1", true)
+		defer stopTree()
+
+		for _, name := range []string{"file1", "dir1/file2", "dir1/dir2/file3"} {
+			_, err := writefs.AppendFile(fsys, name, []byte("hello"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writefs.RemoveAll(fsys, "dir1"))
+
+		assert.Equal(t, []string{"Write dir1/file2", "Remove dir1"}, receive(t, file, "Remove dir1"))
+		assert.Equal(t, []string{"Write dir1/file2", "Remove dir1"}, receive(t, dir, "Remove dir1"))
+		assert.Equal(t, []string{"Write dir1/file2", "Write dir1/dir2/file3", "Remove dir1"}, receive(t, tree, "Remove dir1"))
+	})
+
+	t.Run("does not report unchanged files", func(t *testing.T) {
+		fsys := writefs.WithWatch(newTreeFS())
+		ch, stop := fsys.Watch(".", true)
+		defer stop()
+		file, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly, 0)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		// a later event proves that nothing was reported before it
+		require.NoError(t, writefs.MkDir(fsys, "sentinel", 0755))
+		assert.Equal(t, []string{"Mkdir sentinel"}, receive(t, ch, "Mkdir sentinel"))
+	})
+
+	t.Run("reports TruncateFile", func(t *testing.T) {
+		fsys := writefs.WithWatch(newTreeFS())
+		ch, stop := fsys.Watch(".", true)
+		defer stop()
+		file, err := writefs.OpenFile(fsys, "file1", writefs.WriteOnly, 0)
+		require.NoError(t, err)
+		require.NoError(t, writefs.TruncateFile(file, 0))
+		require.NoError(t, file.Close())
+		assert.Equal(t, []string{"Write file1"}, receive(t, ch, "Write file1"))
+	})
+
+	t.Run("does not block writers", func(t *testing.T) {
+		fsys := writefs.WithWatch(newTreeFS())
+		ch, stop := fsys.Watch(".", true)
+		defer stop()
+		for i := 0; i < 1000; i++ {
+			_, err := writefs.WriteFile(fsys, fmt.Sprintf("file%d", i+10), nil)
+			require.NoError(t, err)
+		}
+		assert.Len(t, receive(t, ch, "Create file1009"), 1000)
+	})
+
+	t.Run("stop closes the channel", func(t *testing.T) {
+		fsys := writefs.WithWatch(newTreeFS())
+		ch, stop := fsys.Watch(".", true)
+		stop()
+		stop()
+		_, err := writefs.WriteFile(fsys, "file1", []byte("hello"))
+		require.NoError(t, err)
+		_, ok := <-ch
+		assert.False(t, ok)
+	})
+}
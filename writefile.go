@@ -0,0 +1,154 @@
+package writefs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// defaultPerm is the permission used to
+// create files when WriteOptions.Perm is zero.
+const defaultPerm = fs.FileMode(0644)
+
+// WriteOptions customizes how WriteFileOpts
+// opens the file it writes.
+type WriteOptions struct {
+	// Perm is the permission used to create the file
+	// if it does not exist. When zero, 0644 is used.
+	Perm fs.FileMode
+	// Append appends buf to the file content
+	// instead of truncating it.
+	Append bool
+	// Exclusive requires that the file must not exist.
+	Exclusive bool
+	// MkdirParents creates missing parent directories
+	// using MkdirAll. Directories are created with Perm,
+	// adding the execute bit wherever the read bit is set.
+	MkdirParents bool
+}
+
+// flag returns the Flag used to open the file.
+func (opts WriteOptions) flag() Flag {
+	flag := WriteOnly | Create
+	if opts.Append {
+		flag |= Append
+	} else {
+		flag |= Truncate
+	}
+	if opts.Exclusive {
+		flag |= Exclusive
+	}
+	return flag
+}
+
+// perm returns the permission used to create the file.
+func (opts WriteOptions) perm() fs.FileMode {
+	if opts.Perm == 0 {
+		return defaultPerm
+	}
+	return opts.Perm
+}
+
+// dirPerm returns the permission used to create parent directories.
+func (opts WriteOptions) dirPerm() fs.FileMode {
+	perm := opts.perm() & fs.ModePerm
+	return perm | (perm&0444)>>2
+}
+
+// WriteFileOpts works like WriteFile, but opens
+// the file as requested by opts.
+// Number of bytes written is returned, and an error if any.
+func WriteFileOpts(fsys fs.FS, name string, buf []byte, opts WriteOptions) (n int, err error) {
+	return writeFile("WriteFileOpts", fsys, name, buf, opts)
+}
+
+// AppendFile appends buf to the named file,
+// creating it with permissions 0644 if it does not exist.
+// Number of bytes written is returned, and an error if any.
+func AppendFile(fsys fs.FS, name string, buf []byte) (n int, err error) {
+	return writeFile("AppendFile", fsys, name, buf, WriteOptions{Append: true})
+}
+
+// writeFile opens the named file as requested by opts,
+// writes buf in it and closes it. Errors are reported as
+// returned by operation op.
+func writeFile(op string, fsys fs.FS, name string, buf []byte, opts WriteOptions) (n int, err error) {
+	var file FileWriter
+
+	defer func() {
+		err = closeFile(op, name, file, err)
+		if err != nil {
+			n = 0
+		}
+	}()
+
+	file, err = openForWrite(fsys, name, opts)
+	if err != nil {
+		return
+	}
+
+	n, err = file.Write(buf)
+	return
+}
+
+// WriteFrom works like WriteFileOpts, but streams the content
+// to write from r until EOF, instead of requiring it in memory.
+// If the FileWriter returned by OpenFile implements io.ReaderFrom,
+// its ReadFrom method is used to copy the data.
+// Number of bytes written is returned, and an error if any.
+func WriteFrom(fsys fs.FS, name string, r io.Reader, opts WriteOptions) (n int64, err error) {
+	var file FileWriter
+
+	defer func() {
+		err = closeFile("WriteFrom", name, file, err)
+		if err != nil {
+			n = 0
+		}
+	}()
+
+	file, err = openForWrite(fsys, name, opts)
+	if err != nil {
+		return
+	}
+
+	if rf, ok := file.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(file, r)
+}
+
+// closeFile closes file, if not nil, and returns
+// err, or the error returned by Close if err is nil.
+// The returned error is wrapped in a *fs.PathError
+// reporting operation op.
+func closeFile(op string, name string, file FileWriter, err error) error {
+	if file != nil {
+		errClose := file.Close()
+		if errClose != nil && err == nil {
+			err = errClose
+		}
+	}
+
+	if err != nil {
+		return wrappedPathError(op, name, err)
+	}
+	return nil
+}
+
+// openForWrite validates name, creates parent
+// directories if requested and opens name
+// as requested by opts.
+func openForWrite(fsys fs.FS, name string, opts WriteOptions) (FileWriter, error) {
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
+	}
+
+	if opts.MkdirParents {
+		if err := MkdirAll(fsys, path.Dir(name), opts.dirPerm()); err != nil {
+			return nil, err
+		}
+	}
+
+	return OpenFile(fsys, name, opts.flag(), opts.perm())
+}
@@ -0,0 +1,157 @@
+package writefs_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/parrogo/writefs"
+	mockfs "github.com/parrogo/writefs/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileOpts(t *testing.T) {
+	data := []byte("ciao")
+
+	t.Run("opens file with flags from opts", func(t *testing.T) {
+		cases := map[writefs.Flag]writefs.WriteOptions{
+			writefs.WriteOnly | writefs.Create | writefs.Truncate:                     {},
+			writefs.WriteOnly | writefs.Create | writefs.Append:                       {Append: true},
+			writefs.WriteOnly | writefs.Create | writefs.Truncate | writefs.Exclusive: {Exclusive: true},
+		}
+		for flag, opts := range cases {
+			testfs := mockfs.FS{}
+			writer := mockfs.FileWriter{}
+			writer.On("Write", data).Return(len(data), nil)
+			writer.On("Close").Return(nil)
+			testfs.On("OpenFile", "file1", flag, fs.FileMode(0644)).Return(&writer, nil)
+
+			n, err := writefs.WriteFileOpts(&testfs, "file1", data, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, len(data), n)
+
+			writer.AssertExpectations(t)
+			testfs.AssertExpectations(t)
+		}
+	})
+
+	t.Run("uses Perm", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		writer := mockfs.FileWriter{}
+		writer.On("Write", data).Return(len(data), nil)
+		writer.On("Close").Return(nil)
+		testfs.On("OpenFile", "file1", mock.Anything, fs.FileMode(0600)).Return(&writer, nil)
+
+		_, err := writefs.WriteFileOpts(&testfs, "file1", data, writefs.WriteOptions{Perm: 0600})
+		assert.NoError(t, err)
+		testfs.AssertExpectations(t)
+	})
+
+	t.Run("creates parent directories", func(t *testing.T) {
+		fsys := newTreeFS()
+		_, err := writefs.WriteFileOpts(fsys, "dir5/dir6/file7", data, writefs.WriteOptions{Perm: 0600, MkdirParents: true})
+		require.NoError(t, err)
+
+		info, err := fs.Stat(fsys, "dir5/dir6")
+		require.NoError(t, err)
+		assert.Equal(t, fs.ModeDir|0700, info.Mode())
+
+		actual, err := fs.ReadFile(fsys, "dir5/dir6/file7")
+		require.NoError(t, err)
+		assert.Equal(t, data, actual)
+	})
+
+	t.Run("fails with Exclusive on existing files", func(t *testing.T) {
+		n, err := writefs.WriteFileOpts(newTreeFS(), "file1", data, writefs.WriteOptions{Exclusive: true})
+		assert.Zero(t, n)
+		assert.ErrorIs(t, err, fs.ErrExist)
+		assert.EqualError(t, err, "WriteFileOpts: OpenFile file1: file already exists")
+	})
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		_, err := writefs.WriteFileOpts(newTreeFS(), "/", data, writefs.WriteOptions{})
+		assert.EqualError(t, err, "WriteFileOpts /: invalid argument name: not a valid path")
+	})
+}
+
+func TestAppendFile(t *testing.T) {
+	fsys := newTreeFS()
+
+	n, err := writefs.AppendFile(fsys, "file1", []byte(" mondo"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+
+	_, err = writefs.AppendFile(fsys, "file5", []byte("new"))
+	require.NoError(t, err)
+
+	actual, err := fs.ReadFile(fsys, "file1")
+	require.NoError(t, err)
+	assert.Equal(t, "ciao mondo", string(actual))
+
+	actual, err = fs.ReadFile(fsys, "file5")
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(actual))
+}
+
+// readerFromWriter is a mocked FileWriter
+// that implements io.ReaderFrom
+type readerFromWriter struct {
+	mockfs.FileWriter
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	args := w.Called(r)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func TestWriteFrom(t *testing.T) {
+	data := []byte("a streamed content")
+
+	t.Run("copies reader content", func(t *testing.T) {
+		fsys := newTreeFS()
+		n, err := writefs.WriteFrom(fsys, "file1", bytes.NewReader(data), writefs.WriteOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), n)
+
+		actual, err := fs.ReadFile(fsys, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, data, actual)
+	})
+
+	t.Run("uses io.ReaderFrom when implemented", func(t *testing.T) {
+		r := bytes.NewReader(data)
+		testfs := mockfs.FS{}
+		writer := readerFromWriter{}
+		writer.On("ReadFrom", r).Return(len(data), nil)
+		writer.On("Close").Return(nil)
+		testfs.On("OpenFile", "file1", writefs.WriteOnly|writefs.Create|writefs.Append, fs.FileMode(0644)).Return(&writer, nil)
+
+		n, err := writefs.WriteFrom(&testfs, "file1", r, writefs.WriteOptions{Append: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), n)
+
+		writer.AssertExpectations(t)
+		testfs.AssertExpectations(t)
+	})
+
+	t.Run("wraps Close errors if any", func(t *testing.T) {
+		testfs := mockfs.FS{}
+		writer := mockfs.FileWriter{}
+		writer.On("Write", data).Return(len(data), nil)
+		writer.On("Close").Return(&fs.PathError{Op: "Close", Path: "file1", Err: fs.ErrClosed})
+		testfs.On("OpenFile", "file1", mock.Anything, mock.Anything).Return(&writer, nil)
+
+		n, err := writefs.WriteFrom(&testfs, "file1", bytes.NewReader(data), writefs.WriteOptions{})
+		assert.Zero(t, n)
+		assert.ErrorIs(t, err, fs.ErrClosed)
+		assert.EqualError(t, err, "WriteFrom: Close file1: file already closed")
+	})
+
+	t.Run("return PathError for invalid path", func(t *testing.T) {
+		_, err := writefs.WriteFrom(newTreeFS(), "/", bytes.NewReader(data), writefs.WriteOptions{})
+		assert.EqualError(t, err, "WriteFrom /: invalid argument name: not a valid path")
+	})
+}
@@ -5,6 +5,10 @@
 // and add as single OpenFile method that allows to open files for write,
 // and it works similarly to os.OpenFile.
 //
+// DirFS function returns a WriteFS implementation backed by
+// a directory of the operating system file system, while MemFS
+// is an in-memory implementation that extends fstest.MapFS.
+//
 // There are also two subpackages: writefs/mock contains a WriteFS implementation
 // based on github.com/stretchr/testify/mock that simplify testing of your code;
 // writefs/test is similar to fs/fstest but allows you to check your writefs.WriteFS
@@ -50,9 +54,9 @@ const (
 // OpenFile method could be used to open files for write
 // but also to create directories and delete files or directories.
 // OpenFile is the generalized open call; It opens the named file with
-// specified flags (O_RDONLY etc.).
+// specified flags (ReadOnly etc.).
 //
-// If the file does not exist, and the O_CREATE flag is passed, it is
+// If the file does not exist, and the Create flag is passed, it is
 // created with mode perm. If successful, methods on the
 // returned File can be used for I/O. If there is an error, it will
 // be of type *fs.PathError.
@@ -65,15 +69,49 @@ const (
 // On both these circumstances, the function returns a nil FileWriter
 // even in case of success.
 //
+// On file systems that support symbolic links, OpenFile follows
+// them, except when deleting with the Truncate convention:
+// in that case the link itself is deleted, never its target.
+//
 // If this default semantic of directory creation and deletion is not
 // sufficient or if your filesystem implementation support optimized
 // algorithm, you can implements writefs.RemoveFS or writefs.MkDirFS
 // that allow more control on the operations.
 type WriteFS interface {
+	fs.FS
+	OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error)
+}
+
+// LegacyWriteFS is the interface implemented by file systems
+// written for the previous version of WriteFS, whose OpenFile
+// method accepted an int flag argument.
+//
+// OpenFile function accepts a LegacyWriteFS and converts
+// flag before forwarding the call.
+//
+// Deprecated: implement WriteFS instead.
+type LegacyWriteFS interface {
 	fs.FS
 	OpenFile(name string, flag int, perm fs.FileMode) (FileWriter, error)
 }
 
+// FromLegacy returns a WriteFS that forwards
+// all OpenFile calls to fsys, converting flag to int.
+//
+// Deprecated: implement WriteFS instead.
+func FromLegacy(fsys LegacyWriteFS) WriteFS {
+	return legacyWriteFS{fsys}
+}
+
+type legacyWriteFS struct {
+	LegacyWriteFS
+}
+
+// OpenFile implements WriteFS
+func (fsys legacyWriteFS) OpenFile(name string, flag Flag, perm fs.FileMode) (FileWriter, error) {
+	return fsys.LegacyWriteFS.OpenFile(name, int(flag), perm)
+}
+
 // FileWriter extends fs.File interface with
 // io.Writer, thus requiring implementation to
 // have an additional Write method.
@@ -88,7 +126,8 @@ type FileWriter interface {
 // a read only file. This struct is returned by OpenFile function
 // when ReadOnly flag is used.
 //
-// The Write method always returns an unsupported error.
+// The Write method always returns a *fs.PathError
+// wrapping ErrReadOnlyFile.
 // All other operations will be forwarded to the underlying
 // fs.File instance.
 type ReadOnlyWriteFile struct {
@@ -97,7 +136,11 @@ type ReadOnlyWriteFile struct {
 
 // Write implements io.Writer interface.
 func (f ReadOnlyWriteFile) Write(p []byte) (n int, err error) {
-	return 0, fmt.Errorf("file does not support write: %w", fs.ErrInvalid)
+	var name string
+	if info, err := f.Stat(); err == nil {
+		name = info.Name()
+	}
+	return 0, &fs.PathError{Op: "Write", Path: name, Err: ErrReadOnlyFile}
 }
 
 // openFileReadOnly open a specified file
@@ -111,75 +154,67 @@ func openFileReadOnly(fsInst fs.FS, name string) (FileWriter, error) {
 	return ReadOnlyWriteFile{file}, nil
 }
 
-// # TODO: OpenFile flag argument should be of type Flag not int
-
 // OpenFile is the generalized open call; It opens the named file with
-// specified flags (O_RDONLY etc.).
+// specified flags (ReadOnly etc.).
 //
-// If the file does not exist, and the O_CREATE flag is passed, it is
+// If the file does not exist, and the Create flag is passed, it is
 // created with mode perm. If successful, methods on the
 // returned File can be used for I/O. If there is an error, it will
 // be of type *fs.PathError.
 //
 // The function use the given fsys argument to open the file.
 // if fsys implements WriteFS, the call is forwarded to its
-// OpenFile method.
+// OpenFile method. Before that, flag is checked using
+// Flag.Validate method.
 //
 // Otherwise, if read only access is required, the call is forwarded
 // to fsys Open method, and the results wrapped in a ReadOnlyWriteFile
 // struct.
 //
-// Otherwise, the function return an `unsupported` error.
-func OpenFile(fsys fs.FS, name string, flag int, perm fs.FileMode) (w FileWriter, err error) {
+// Otherwise, the function returns a *fs.PathError wrapping ErrNotWritable.
+func OpenFile(fsys fs.FS, name string, flag Flag, perm fs.FileMode) (w FileWriter, err error) {
 	if !fs.ValidPath(name) {
 		err = fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
 		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: err}
 	}
 
-	if fs, ok := fsys.(WriteFS); ok {
-		return fs.OpenFile(name, flag, perm)
+	if err := flag.Validate(); err != nil {
+		return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: err}
+	}
+
+	switch fsys := fsys.(type) {
+	case WriteFS:
+		return fsys.OpenFile(name, flag, perm)
+	case LegacyWriteFS:
+		return fsys.OpenFile(name, int(flag), perm)
 	}
 
-	if flag == os.O_RDONLY {
+	if flag == ReadOnly {
 		return openFileReadOnly(fsys, name)
 	}
 
-	err = fmt.Errorf("%w fsys: does not implement WriteFS", fs.ErrInvalid)
-	return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: err}
+	return nil, &fs.PathError{Op: "OpenFile", Path: name, Err: ErrNotWritable}
+}
+
+// OpenFileInt calls OpenFile converting flag to Flag type.
+// It allows callers that computes flag using os.O_* constants
+// to keep working unchanged.
+//
+// Deprecated: use OpenFile with Flag constants instead.
+func OpenFileInt(fsys fs.FS, name string, flag int, perm fs.FileMode) (FileWriter, error) {
+	return OpenFile(fsys, name, Flag(flag), perm)
 }
 
 // WriteFile is an utility function that opens a file
 // using OpenFile function, write buf arg in the file
 // and closes it immediately after.
 // Number of writes written is returned an error if any.
+//
+// The file is truncated if it exists, and created with
+// permissions 0644 otherwise. Use WriteFileOpts to
+// customize this behavior.
 func WriteFile(fsys fs.FS, name string, buf []byte) (n int, err error) {
-	var file FileWriter
-
-	defer func() {
-		if file != nil {
-			errClose := file.Close()
-			if errClose != nil && err == nil {
-				err = errClose
-			}
-		}
-
-		if err != nil {
-			n = 0
-			err = wrappedPathError("WriteFile", name, err)
-		}
-	}()
-
-	if !fs.ValidPath(name) {
-		return 0, fmt.Errorf("%w name: not a valid path", fs.ErrInvalid)
-	}
-
-	file, err = OpenFile(fsys, name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fs.FileMode(0644))
-	if err != nil {
-		return
-	}
-
-	n, err = file.Write(buf)
-	return
+	return writeFile("WriteFile", fsys, name, buf, WriteOptions{})
 }
 
 func wrappedPathError(op string, name string, err error) error {
@@ -4,7 +4,6 @@ import (
 	"embed"
 	"errors"
 	"io/fs"
-	"os"
 	"testing"
 
 	"github.com/parrogo/writefs"
@@ -136,7 +135,7 @@ func TestWriteFS(t *testing.T) {
 				testfs := mockfs.FS{}
 				testfs.On("OpenFile", "dir1/file2", mock.Anything, mock.Anything).Return(nil, nil)
 
-				f, err := writefs.OpenFile(&testfs, "dir1/file2", int(writefs.WriteOnly), fs.FileMode(0644))
+				f, err := writefs.OpenFile(&testfs, "dir1/file2", writefs.WriteOnly, fs.FileMode(0644))
 				assert.NoError(err)
 				assert.Nil(f)
 
@@ -147,7 +146,7 @@ func TestWriteFS(t *testing.T) {
 				testfs := mockfs.FS{}
 				testfs.On("OpenFile", "dir1/file2", mock.Anything, mock.Anything).Return(nil, nil)
 
-				f, err := writefs.OpenFile(&testfs, "dir1/file2", int(writefs.ReadOnly), fs.FileMode(0))
+				f, err := writefs.OpenFile(&testfs, "dir1/file2", writefs.ReadOnly, fs.FileMode(0))
 				assert.NoError(err)
 				assert.Nil(f)
 
@@ -155,8 +154,8 @@ func TestWriteFS(t *testing.T) {
 			})
 		})
 
-		t.Run("Call fsys.Open for os.O_RDONLY when fsys not implements writefs.WriteFS", func(t *testing.T) {
-			f, err := writefs.OpenFile(fixtureFS, "dir1/file2", os.O_RDONLY, fs.FileMode(0))
+		t.Run("Call fsys.Open for writefs.ReadOnly when fsys not implements writefs.WriteFS", func(t *testing.T) {
+			f, err := writefs.OpenFile(fixtureFS, "dir1/file2", writefs.ReadOnly, fs.FileMode(0))
 			assert.NoError(err)
 			require.NotNil(f)
 
@@ -175,7 +174,7 @@ func TestWriteFS(t *testing.T) {
 		})
 
 		t.Run("return PathError for unvalid path", func(t *testing.T) {
-			f, err := writefs.OpenFile(fixtureFS, "/", os.O_RDONLY, fs.FileMode(0))
+			f, err := writefs.OpenFile(fixtureFS, "/", writefs.ReadOnly, fs.FileMode(0))
 			assert.Nil(f)
 			require.Error(err)
 			assert.ErrorIs(err, fs.ErrInvalid)
@@ -197,19 +196,19 @@ func TestWriteFS(t *testing.T) {
 		})
 
 		t.Run("return original error for RO open", func(t *testing.T) {
-			f, err := writefs.OpenFile(fixtureFS, "notexists", os.O_RDONLY, fs.FileMode(0))
+			f, err := writefs.OpenFile(fixtureFS, "notexists", writefs.ReadOnly, fs.FileMode(0))
 			assert.True(errors.Is(err, fs.ErrNotExist))
 			assert.Nil(f)
 		})
 
 		t.Run("return invalid for RO open for write", func(t *testing.T) {
-			f, err := writefs.OpenFile(fixtureFS, "notexists", os.O_WRONLY, fs.FileMode(0))
+			f, err := writefs.OpenFile(fixtureFS, "notexists", writefs.WriteOnly, fs.FileMode(0))
 			assert.True(errors.Is(err, fs.ErrInvalid))
 			assert.Nil(f)
 		})
 
 		t.Run("return PathError for unvalid paths", func(t *testing.T) {
-			f, err := writefs.OpenFile(fixtureFS, "/", os.O_RDONLY, fs.FileMode(0))
+			f, err := writefs.OpenFile(fixtureFS, "/", writefs.ReadOnly, fs.FileMode(0))
 			_, ok := err.(*fs.PathError)
 			assert.True(ok)
 			assert.Nil(f)